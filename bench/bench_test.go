@@ -0,0 +1,217 @@
+package bench
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/lleo/go-hamt/hamt64"
+	"github.com/lleo/go-hamt/internal/workload"
+)
+
+// numKeys is the number of entries of the Hamts read by the benchmarks.
+const numKeys = 1 << 16
+
+// patternLen is the length of a precomputed access pattern; benchmarks
+// longer than it cycle through it.
+const patternLen = 1 << 16
+
+// zipfS is the s parameter of the Zipfian distribution.
+const zipfS = 1.1
+
+var widths = []int{32, 64}
+
+var tableOptions = []int{hamt64.HybridTables, hamt64.FixedTables,
+	hamt64.SparseTables, hamt64.CompactTables}
+
+var modes = []bool{true, false}
+
+var keyTypes = []string{workload.IntKeys, workload.StringKeys,
+	workload.BytesKeys}
+
+var dists = []string{workload.Uniform, workload.Zipf}
+
+// readRatios are the percentages of Gets of the Mixed benchmarks; the rest
+// are Puts.
+var readRatios = []int{90, 50}
+
+// newTarget returns an empty Target of the given width, table option, and
+// mode with numKeys keys of keyType; the same keys for every benchmark.
+func newTarget(width, tblOpt int, functional bool, keyType string) workload.Target {
+	return workload.NewTarget(width, workload.RandomKeys(keyType, numKeys, 1),
+		functional, tblOpt)
+}
+
+// accessPattern returns patternLen key indexes drawn from dist; the same ones
+// for every benchmark.
+func accessPattern(dist string) []int {
+	return workload.AccessPattern(dist, zipfS, rand.New(rand.NewSource(2)),
+		numKeys, patternLen)
+}
+
+// fill puts every key of h and returns the heap bytes this took per entry.
+func fill(h workload.Target, n int) float64 {
+	var before = workload.HeapAlloc()
+	for i := 0; i < n; i++ {
+		h.Put(i, i)
+	}
+	var after = workload.HeapAlloc()
+	if after < before {
+		return 0
+	}
+	return float64(after-before) / float64(n)
+}
+
+// forEachConfig runs fn as a sub-benchmark, named
+// <width>/<table option>/<mode>/<key type>, for every combination of width,
+// table option, and mode, and of the given key types.
+func forEachConfig(
+	b *testing.B,
+	keyTypes []string,
+	fn func(b *testing.B, width, tblOpt int, functional bool, keyType string),
+) {
+	for _, width := range widths {
+		for _, tblOpt := range tableOptions {
+			for _, functional := range modes {
+				var mode = workload.ModeName(functional)
+				for _, keyType := range keyTypes {
+					var name = fmt.Sprintf("hamt%d/%s/%s/%s", width,
+						hamt64.TableOptionName[tblOpt], mode, keyType)
+					var width, tblOpt = width, tblOpt
+					var functional, keyType = functional, keyType
+					b.Run(name, func(b *testing.B) {
+						fn(b, width, tblOpt, functional, keyType)
+					})
+				}
+			}
+		}
+	}
+}
+
+// BenchmarkGet looks up keys of a Hamt of numKeys entries with a uniform or
+// Zipfian distribution. It also reports the heap bytes per entry of the Hamt.
+func BenchmarkGet(b *testing.B) {
+	forEachConfig(b, keyTypes,
+		func(b *testing.B, width, tblOpt int, functional bool, keyType string) {
+			var h = newTarget(width, tblOpt, functional, keyType)
+			var bytesPerEntry = fill(h, numKeys)
+
+			for _, dist := range dists {
+				var idxs = accessPattern(dist)
+				b.Run(dist, func(b *testing.B) {
+					b.ReportAllocs()
+					b.ResetTimer()
+					for i := 0; i < b.N; i++ {
+						if !h.Get(idxs[i%patternLen]) {
+							b.Fatalf("Get of key #%d failed", idxs[i%patternLen])
+						}
+					}
+					b.ReportMetric(bytesPerEntry, "B/entry")
+				})
+			}
+		})
+}
+
+// BenchmarkPut replaces the values of keys of a Hamt of numKeys entries, with
+// a uniform or Zipfian distribution.
+func BenchmarkPut(b *testing.B) {
+	forEachConfig(b, keyTypes,
+		func(b *testing.B, width, tblOpt int, functional bool, keyType string) {
+			var h = newTarget(width, tblOpt, functional, keyType)
+			fill(h, numKeys)
+
+			for _, dist := range dists {
+				var idxs = accessPattern(dist)
+				b.Run(dist, func(b *testing.B) {
+					b.ReportAllocs()
+					b.ResetTimer()
+					for i := 0; i < b.N; i++ {
+						if h.Put(idxs[i%patternLen], i) {
+							b.Fatalf("Put of key #%d added it",
+								idxs[i%patternLen])
+						}
+					}
+				})
+			}
+		})
+}
+
+// BenchmarkInsert builds a Hamt of numKeys entries from empty, over and over.
+func BenchmarkInsert(b *testing.B) {
+	forEachConfig(b, keyTypes,
+		func(b *testing.B, width, tblOpt int, functional bool, keyType string) {
+			var h = newTarget(width, tblOpt, functional, keyType)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				var j = i % numKeys
+				if j == 0 {
+					h.Reset()
+				}
+				if !h.Put(j, i) {
+					b.Fatalf("Put of key #%d did not add it", j)
+				}
+			}
+		})
+}
+
+// BenchmarkDel deletes every key of a Hamt of numKeys entries, in a random
+// order, and refills it when it is empty; the refill is not timed.
+func BenchmarkDel(b *testing.B) {
+	forEachConfig(b, keyTypes,
+		func(b *testing.B, width, tblOpt int, functional bool, keyType string) {
+			var h = newTarget(width, tblOpt, functional, keyType)
+			var order = workload.DeleteOrder(workload.Uniform,
+				rand.New(rand.NewSource(3)), numKeys)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				var j = i % numKeys
+				if j == 0 {
+					b.StopTimer()
+					h.Reset()
+					fill(h, numKeys)
+					b.StartTimer()
+				}
+				if !h.Del(order[j]) {
+					b.Fatalf("Del of key #%d failed", order[j])
+				}
+			}
+		})
+}
+
+// BenchmarkMixed interleaves Gets and Puts of existing string keys, with a
+// Zipfian distribution, at each of the readRatios.
+func BenchmarkMixed(b *testing.B) {
+	forEachConfig(b, []string{workload.StringKeys},
+		func(b *testing.B, width, tblOpt int, functional bool, keyType string) {
+			var h = newTarget(width, tblOpt, functional, keyType)
+			fill(h, numKeys)
+			var idxs = accessPattern(workload.Zipf)
+
+			for _, ratio := range readRatios {
+				// which ops are reads is decided up front, with its own
+				// random source, so every configuration runs the same mix
+				var rnd = rand.New(rand.NewSource(4))
+				var reads = make([]bool, patternLen)
+				for i := range reads {
+					reads[i] = rnd.Intn(100) < ratio
+				}
+
+				b.Run(fmt.Sprintf("read%d", ratio), func(b *testing.B) {
+					b.ReportAllocs()
+					b.ResetTimer()
+					for i := 0; i < b.N; i++ {
+						var j = i % patternLen
+						if reads[j] {
+							h.Get(idxs[j])
+						} else {
+							h.Put(idxs[j], i)
+						}
+					}
+				})
+			}
+		})
+}
@@ -0,0 +1,23 @@
+/*
+Package bench holds the benchmarks used to choose a Hamt configuration. It has
+no API; run it with
+
+	go test -run XXX -bench . ./bench
+
+Unlike the benchmarks of the hamt, hamt32, and hamt64 packages, which are
+selected with the -F, -S, -H, -f, and -t flags of their TestMain, every
+combination of hash width, table option, and mode is a sub-benchmark named
+
+	hamt<width>/<table option>/<mode>/<key type>/<distribution or read ratio>
+
+where Insert and Del have no last element. Any subset can be picked with
+-bench, for example
+
+	go test -run XXX -bench 'Get/hamt64/[^/]+/functional/string/zipf' ./bench
+
+Keys are int, string, or []byte keys (hamt64.Int64Key, StringKey, and
+ByteSliceKey) and are accessed with a uniform or a Zipfian distribution.
+Every benchmark reports its allocations, and the Get benchmarks also report
+the heap bytes used per entry of the Hamt they read.
+*/
+package bench
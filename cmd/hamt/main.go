@@ -0,0 +1,311 @@
+/*
+Command hamt builds hamt32 and hamt64 data structures from a set of keys,
+reports their structure and memory use, and times Get, Put, Del, and Range
+operations against them. It replaces the old runbench.sh, compare.sh, and
+run-bench-mean-stddev.* scripts with something that does not depend on GOPATH
+or benchcmp and that can be pointed at your own keys.
+
+Usage:
+
+	hamt [flags]
+
+Keys are read one per line from the file named by -keys, or from stdin if
+-keys is "-". Without -keys, -n sequential keys "aaa", "aab", ... are
+generated.
+
+Every combination of the -width, -mode, and -tables flags is built and
+measured. Each flag takes a comma separated list or "all". For example:
+
+	hamt -keys words.txt -width 64 -tables all -ops get,put -dist zipf -format csv
+
+The output is a text table, JSON, or CSV (-format text|json|csv). Results are
+deterministic for a given -seed, so table option decisions can be reproduced.
+*/
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/lleo/go-hamt/hamt64"
+)
+
+// config holds the parsed command line flags.
+type config struct {
+	keysFn   string
+	n        int
+	widths   []int
+	modes    []bool // functional?
+	tables   []int
+	ops      []string
+	dist     string
+	zipfS    float64
+	iters    int
+	seed     int64
+	format   string
+	validate bool
+}
+
+var allOps = []string{"get", "put", "del", "range"}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("hamt: ")
+
+	var cfg, err = parseFlags(os.Args[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var keys []string
+	keys, err = loadKeys(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(keys) == 0 {
+		log.Fatal("no keys to work with")
+	}
+
+	var results []*result
+	for _, width := range cfg.widths {
+		for _, functional := range cfg.modes {
+			for _, tblOpt := range cfg.tables {
+				var r = run(cfg, keys, width, functional, tblOpt)
+				results = append(results, r)
+			}
+		}
+	}
+
+	err = writeResults(os.Stdout, cfg.format, results)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func parseFlags(args []string) (*config, error) {
+	var fs = flag.NewFlagSet("hamt", flag.ContinueOnError)
+
+	var cfg = new(config)
+	var widths, modes, tables, ops string
+
+	fs.StringVar(&cfg.keysFn, "keys", "",
+		"file of keys, one per line; \"-\" for stdin; default generates -n keys")
+	fs.IntVar(&cfg.n, "n", 100000,
+		"number of keys to generate, or maximum number of keys to load")
+	fs.StringVar(&widths, "width", "all", "hash widths: 32, 64, or all")
+	fs.StringVar(&modes, "mode", "all",
+		"Hamt modes: functional, transient, or all")
+	fs.StringVar(&tables, "tables", "all",
+		"table options: hybrid, fixed, sparse, compact, or all")
+	fs.StringVar(&ops, "ops", "all", "operations to time: get,put,del,range or all")
+	fs.StringVar(&cfg.dist, "dist", "uniform",
+		"key access distribution: uniform, zipf, or sequential")
+	fs.Float64Var(&cfg.zipfS, "zipf-s", 1.1, "zipf distribution s parameter (> 1)")
+	fs.IntVar(&cfg.iters, "iters", 1000000, "number of timed Get and Put operations")
+	fs.Int64Var(&cfg.seed, "seed", 1, "random seed for the access distribution")
+	fs.StringVar(&cfg.format, "format", "text", "output format: text, json, or csv")
+	fs.BoolVar(&cfg.validate, "validate", false,
+		"call Validate() on every Hamt after it is built")
+
+	var err = fs.Parse(args)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.widths, err = parseWidths(widths)
+	if err != nil {
+		return nil, err
+	}
+	cfg.modes, err = parseModes(modes)
+	if err != nil {
+		return nil, err
+	}
+	cfg.tables, err = parseTables(tables)
+	if err != nil {
+		return nil, err
+	}
+	cfg.ops, err = parseOps(ops)
+	if err != nil {
+		return nil, err
+	}
+
+	switch cfg.dist {
+	case "uniform", "zipf", "sequential":
+	default:
+		return nil, fmt.Errorf("unknown -dist %q", cfg.dist)
+	}
+	if cfg.dist == "zipf" && cfg.zipfS <= 1 {
+		return nil, fmt.Errorf("-zipf-s must be > 1; got %g", cfg.zipfS)
+	}
+
+	switch cfg.format {
+	case "text", "json", "csv":
+	default:
+		return nil, fmt.Errorf("unknown -format %q", cfg.format)
+	}
+
+	if cfg.n <= 0 || cfg.iters <= 0 {
+		return nil, fmt.Errorf("-n and -iters must be positive")
+	}
+
+	return cfg, nil
+}
+
+func splitList(s string) []string {
+	var parts = strings.Split(s, ",")
+	var list = make([]string, 0, len(parts))
+	for _, p := range parts {
+		p = strings.ToLower(strings.TrimSpace(p))
+		if p != "" {
+			list = append(list, p)
+		}
+	}
+	return list
+}
+
+func parseWidths(s string) ([]int, error) {
+	if s == "all" {
+		return []int{32, 64}, nil
+	}
+	var widths []int
+	for _, p := range splitList(s) {
+		switch p {
+		case "32":
+			widths = append(widths, 32)
+		case "64":
+			widths = append(widths, 64)
+		default:
+			return nil, fmt.Errorf("unknown -width %q", p)
+		}
+	}
+	return widths, nil
+}
+
+func parseModes(s string) ([]bool, error) {
+	if s == "all" {
+		return []bool{false, true}, nil
+	}
+	var modes []bool
+	for _, p := range splitList(s) {
+		switch p {
+		case "functional":
+			modes = append(modes, true)
+		case "transient":
+			modes = append(modes, false)
+		default:
+			return nil, fmt.Errorf("unknown -mode %q", p)
+		}
+	}
+	return modes, nil
+}
+
+// The table option constants are the same in hamt32, hamt64 and hamt.
+const (
+	hybridTables  = hamt64.HybridTables
+	fixedTables   = hamt64.FixedTables
+	sparseTables  = hamt64.SparseTables
+	compactTables = hamt64.CompactTables
+)
+
+// tableOptions maps the -tables names to the table option constants, which
+// are identical for hamt32 and hamt64.
+var tableOptions = map[string]int{
+	"hybrid":  hybridTables,
+	"fixed":   fixedTables,
+	"sparse":  sparseTables,
+	"compact": compactTables,
+}
+
+func parseTables(s string) ([]int, error) {
+	if s == "all" {
+		return []int{hybridTables, fixedTables, sparseTables, compactTables},
+			nil
+	}
+	var tables []int
+	for _, p := range splitList(s) {
+		var opt, ok = tableOptions[p]
+		if !ok {
+			return nil, fmt.Errorf("unknown -tables %q", p)
+		}
+		tables = append(tables, opt)
+	}
+	return tables, nil
+}
+
+func parseOps(s string) ([]string, error) {
+	if s == "all" {
+		return allOps, nil
+	}
+	var ops []string
+	for _, p := range splitList(s) {
+		switch p {
+		case "get", "put", "del", "range":
+			ops = append(ops, p)
+		default:
+			return nil, fmt.Errorf("unknown -ops %q", p)
+		}
+	}
+	return ops, nil
+}
+
+// loadKeys reads the keys named by cfg.keysFn, or generates cfg.n sequential
+// keys. Duplicate keys are dropped so every key is a distinct entry.
+func loadKeys(cfg *config) ([]string, error) {
+	if cfg.keysFn == "" {
+		return generateKeys(cfg.n), nil
+	}
+
+	var r io.Reader = os.Stdin
+	if cfg.keysFn != "-" {
+		var f, err = os.Open(cfg.keysFn)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var keys []string
+	var seen = make(map[string]bool)
+	var scanner = bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() && len(keys) < cfg.n {
+		var k = scanner.Text()
+		if k == "" || seen[k] {
+			continue
+		}
+		seen[k] = true
+		keys = append(keys, k)
+	}
+
+	return keys, scanner.Err()
+}
+
+// generateKeys returns n distinct keys "aaa", "aab", ... the same sequence the
+// test suites use.
+func generateKeys(n int) []string {
+	var keys = make([]string, n)
+	var s = []byte("aaa")
+	for i := range keys {
+		keys[i] = string(s)
+		s = incKey(s)
+	}
+	return keys
+}
+
+// incKey increments a lowercase string as if it were a base 26 number.
+func incKey(s []byte) []byte {
+	for i := len(s) - 1; i >= 0; i-- {
+		if s[i] < 'z' {
+			s[i]++
+			return s
+		}
+		s[i] = 'a'
+	}
+	return append([]byte{'a'}, s...)
+}
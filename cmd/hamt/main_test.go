@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+)
+
+func TestParseFlags(t *testing.T) {
+	var cfg, err = parseFlags([]string{
+		"-width", "64", "-mode", "functional", "-tables", "hybrid,sparse",
+		"-ops", "get,del", "-dist", "zipf",
+	})
+	if err != nil {
+		t.Fatalf("parseFlags failed: %s", err)
+	}
+	if len(cfg.widths) != 1 || cfg.widths[0] != 64 {
+		t.Fatalf("cfg.widths,%v != [64]", cfg.widths)
+	}
+	if len(cfg.modes) != 1 || !cfg.modes[0] {
+		t.Fatalf("cfg.modes,%v != [true]", cfg.modes)
+	}
+	if len(cfg.tables) != 2 ||
+		cfg.tables[0] != hybridTables || cfg.tables[1] != sparseTables {
+		t.Fatalf("cfg.tables,%v != [hybrid sparse]", cfg.tables)
+	}
+	if len(cfg.ops) != 2 {
+		t.Fatalf("cfg.ops,%v != [get del]", cfg.ops)
+	}
+
+	cfg, err = parseFlags([]string{"-tables", "all"})
+	if err != nil {
+		t.Fatalf("parseFlags failed: %s", err)
+	}
+	if len(cfg.tables) != 4 || cfg.tables[3] != compactTables {
+		t.Fatalf("cfg.tables,%v != [hybrid fixed sparse compact]", cfg.tables)
+	}
+
+	var bad = [][]string{
+		{"-width", "16"},
+		{"-mode", "both"},
+		{"-tables", "compressed"},
+		{"-ops", "scan"},
+		{"-dist", "normal"},
+		{"-dist", "zipf", "-zipf-s", "1"},
+		{"-format", "xml"},
+	}
+	for _, args := range bad {
+		if _, err = parseFlags(args); err == nil {
+			t.Fatalf("parseFlags(%q) did not fail", args)
+		}
+	}
+}
+
+func TestRunCSV(t *testing.T) {
+	var cfg, err = parseFlags([]string{
+		"-n", "2000", "-iters", "5000", "-format", "csv", "-validate",
+	})
+	if err != nil {
+		t.Fatalf("parseFlags failed: %s", err)
+	}
+
+	var keys []string
+	keys, err = loadKeys(cfg)
+	if err != nil {
+		t.Fatalf("loadKeys failed: %s", err)
+	}
+
+	var results []*result
+	for _, width := range cfg.widths {
+		for _, functional := range cfg.modes {
+			for _, tblOpt := range cfg.tables {
+				var r = run(cfg, keys, width, functional, tblOpt)
+				if r.Stats.KeyVals != uint(len(keys)) {
+					t.Fatalf("%d/%s/%s: Stats.KeyVals,%d != len(keys),%d",
+						width, r.Mode, r.Tables, r.Stats.KeyVals, len(keys))
+				}
+				results = append(results, r)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	err = writeResults(&buf, cfg.format, results)
+	if err != nil {
+		t.Fatalf("writeResults failed: %s", err)
+	}
+
+	var rows [][]string
+	rows, err = csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("csv.ReadAll failed: %s", err)
+	}
+	if len(rows) != 1+2*2*4 {
+		t.Fatalf("expected %d csv rows; got %d", 1+2*2*4, len(rows))
+	}
+}
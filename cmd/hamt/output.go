@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+)
+
+func writeResults(w io.Writer, format string, results []*result) error {
+	switch format {
+	case "json":
+		var enc = json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+	case "csv":
+		return writeCSV(w, results)
+	}
+	return writeText(w, results)
+}
+
+var columns = []string{
+	"width", "mode", "tables", "dist", "keys",
+	"maxDepth", "fixedTables", "sparseTables", "flatLeafs", "collisionLeafs",
+	"heapBytes", "bytesPerEntry",
+	"buildNsPerOp", "getNsPerOp", "putNsPerOp", "delNsPerOp", "rangeNsPerKeyVal",
+}
+
+func formatNs(ns float64) string {
+	if ns == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(ns, 'f', 1, 64)
+}
+
+func (r *result) row() []string {
+	return []string{
+		strconv.Itoa(r.Width),
+		r.Mode,
+		r.Tables,
+		r.Dist,
+		strconv.Itoa(r.Keys),
+		strconv.FormatUint(uint64(r.Stats.MaxDepth), 10),
+		strconv.FormatUint(uint64(r.Stats.FixedTables), 10),
+		strconv.FormatUint(uint64(r.Stats.SparseTables), 10),
+		strconv.FormatUint(uint64(r.Stats.FlatLeafs), 10),
+		strconv.FormatUint(uint64(r.Stats.CollisionLeafs), 10),
+		strconv.FormatUint(r.HeapBytes, 10),
+		strconv.FormatFloat(r.BytesPerEntry, 'f', 1, 64),
+		formatNs(r.BuildNs),
+		formatNs(r.GetNs),
+		formatNs(r.PutNs),
+		formatNs(r.DelNs),
+		formatNs(r.RangeNs),
+	}
+}
+
+func writeCSV(w io.Writer, results []*result) error {
+	var cw = csv.NewWriter(w)
+	cw.Write(columns)
+	for _, r := range results {
+		cw.Write(r.row())
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func writeText(w io.Writer, results []*result) error {
+	var tw = tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.AlignRight)
+	for i, c := range columns {
+		if i > 0 {
+			fmt.Fprint(tw, "\t")
+		}
+		fmt.Fprint(tw, c)
+	}
+	fmt.Fprintln(tw, "\t")
+	for _, r := range results {
+		for i, c := range r.row() {
+			if i > 0 {
+				fmt.Fprint(tw, "\t")
+			}
+			fmt.Fprint(tw, c)
+		}
+		fmt.Fprintln(tw, "\t")
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"log"
+	"math/rand"
+	"time"
+
+	"github.com/lleo/go-hamt/hamt64"
+	"github.com/lleo/go-hamt/internal/workload"
+)
+
+// result is the measurement of one width, mode, and table option
+// combination. Timings are in nanoseconds per operation; an operation that
+// was not requested has a zero timing.
+type result struct {
+	Width         int            `json:"width"`
+	Mode          string         `json:"mode"`
+	Tables        string         `json:"tables"`
+	Dist          string         `json:"dist"`
+	Keys          int            `json:"keys"`
+	Stats         workload.Stats `json:"stats"`
+	HeapBytes     uint64         `json:"heapBytes"`
+	BytesPerEntry float64        `json:"bytesPerEntry"`
+	BuildNs       float64        `json:"buildNsPerOp"`
+	GetNs         float64        `json:"getNsPerOp,omitempty"`
+	PutNs         float64        `json:"putNsPerOp,omitempty"`
+	DelNs         float64        `json:"delNsPerOp,omitempty"`
+	RangeNs       float64        `json:"rangeNsPerKeyVal,omitempty"`
+}
+
+func nsPerOp(d time.Duration, n int) float64 {
+	return float64(d.Nanoseconds()) / float64(n)
+}
+
+// run builds the Hamt for one configuration and times the requested
+// operations against it.
+func run(
+	cfg *config,
+	keys []string,
+	width int,
+	functional bool,
+	tblOpt int,
+) *result {
+	var r = &result{
+		Width:  width,
+		Mode:   workload.ModeName(functional),
+		Tables: hamt64.TableOptionName[tblOpt],
+		Dist:   cfg.dist,
+		Keys:   len(keys),
+	}
+
+	var t = workload.NewTarget(width, workload.StringKeysOf(keys), functional,
+		tblOpt)
+
+	// Build; this measures both insertion speed and memory use.
+	var before = workload.HeapAlloc()
+	var start = time.Now()
+	for i := range keys {
+		if !t.Put(i, i) {
+			log.Fatalf("%d/%s/%s: failed to insert key %q",
+				width, r.Mode, r.Tables, keys[i])
+		}
+	}
+	r.BuildNs = nsPerOp(time.Since(start), len(keys))
+	var after = workload.HeapAlloc()
+	if after > before {
+		r.HeapBytes = after - before
+	}
+	r.BytesPerEntry = float64(r.HeapBytes) / float64(len(keys))
+	r.Stats = t.Stats()
+
+	if cfg.validate {
+		var err = t.Validate()
+		if err != nil {
+			log.Fatalf("%d/%s/%s: %s", width, r.Mode, r.Tables, err)
+		}
+	}
+
+	var rnd = rand.New(rand.NewSource(cfg.seed))
+	var idxs = workload.AccessPattern(cfg.dist, cfg.zipfS, rnd, len(keys),
+		cfg.iters)
+
+	for _, op := range cfg.ops {
+		switch op {
+		case "get":
+			start = time.Now()
+			for _, i := range idxs {
+				if !t.Get(i) {
+					log.Fatalf("%d/%s/%s: failed to find key %q",
+						width, r.Mode, r.Tables, keys[i])
+				}
+			}
+			r.GetNs = nsPerOp(time.Since(start), len(idxs))
+		case "put":
+			// Every key is present, so these Puts replace values; an update
+			// workload following the access distribution.
+			start = time.Now()
+			for _, i := range idxs {
+				t.Put(i, i)
+			}
+			r.PutNs = nsPerOp(time.Since(start), len(idxs))
+		case "range":
+			start = time.Now()
+			var n = t.Range()
+			r.RangeNs = nsPerOp(time.Since(start), n)
+		}
+	}
+
+	// Del empties the Hamt, so it is always timed last.
+	for _, op := range cfg.ops {
+		if op != "del" {
+			continue
+		}
+		var order = workload.DeleteOrder(cfg.dist, rnd, len(keys))
+		start = time.Now()
+		for _, i := range order {
+			if !t.Del(i) {
+				log.Fatalf("%d/%s/%s: failed to delete key %q",
+					width, r.Mode, r.Tables, keys[i])
+			}
+		}
+		r.DelNs = nsPerOp(time.Since(start), len(order))
+	}
+
+	return r
+}
@@ -0,0 +1,92 @@
+package hamt
+
+import (
+	"math"
+
+	"github.com/lleo/go-hamt/hamt32"
+	"github.com/lleo/go-hamt/hamt64"
+)
+
+// CollisionEstimate compares how often a sample of keys collides under the
+// hamt32 and hamt64 hash functions. See EstimateCollisions().
+type CollisionEstimate struct {
+	// Keys is the number of distinct keys in the sample.
+	Keys uint
+
+	// Colliding32 and Colliding64 are the number of keys in the sample that
+	// share their HashVal with at least one other key in the sample.
+	Colliding32 uint
+	Colliding64 uint
+
+	// Groups32 and Groups64 are the number of distinct HashVals shared by two
+	// or more keys; that is, the number of collisionLeafs a Hamt built from
+	// the sample would contain.
+	Groups32 uint
+	Groups64 uint
+
+	// Probability32 and Probability64 are the birthday bound probabilities
+	// that at least one collision occurs among Keys random HashVals of the
+	// hamt32 and hamt64 widths (30 and 60 bits).
+	Probability32 float64
+	Probability64 float64
+
+	// ExpectedPairs32 and ExpectedPairs64 are the expected number of colliding
+	// pairs among Keys random HashVals of the hamt32 and hamt64 widths.
+	ExpectedPairs32 float64
+	ExpectedPairs64 float64
+}
+
+// EstimateCollisions hashes every key of the sample with hamt32.CalcHash and
+// hamt64.CalcHash, which are the hash functions used by the StringKey and
+// ByteSliceKey types, and counts the observed collisions. It also computes the
+// collision probability a perfectly random hash of each width would give for
+// a sample of that size. Duplicate keys are ignored.
+//
+// For StringKey values convert each string to a []byte.
+func EstimateCollisions(keys [][]byte) *CollisionEstimate {
+	var seen = make(map[string]bool, len(keys))
+	var hvs32 = make(map[hamt32.HashVal]uint, len(keys))
+	var hvs64 = make(map[hamt64.HashVal]uint, len(keys))
+
+	for _, k := range keys {
+		if seen[string(k)] {
+			continue
+		}
+		seen[string(k)] = true
+		hvs32[hamt32.CalcHash(k)]++
+		hvs64[hamt64.CalcHash(k)]++
+	}
+
+	var est = new(CollisionEstimate)
+	est.Keys = uint(len(seen))
+
+	for _, n := range hvs32 {
+		if n > 1 {
+			est.Colliding32 += n
+			est.Groups32++
+		}
+	}
+	for _, n := range hvs64 {
+		if n > 1 {
+			est.Colliding64 += n
+			est.Groups64++
+		}
+	}
+
+	const bits32 = hamt32.DepthLimit * hamt32.NumIndexBits
+	const bits64 = hamt64.DepthLimit * hamt64.NumIndexBits
+
+	est.ExpectedPairs32 = expectedPairs(est.Keys, bits32)
+	est.ExpectedPairs64 = expectedPairs(est.Keys, bits64)
+	est.Probability32 = -math.Expm1(-est.ExpectedPairs32)
+	est.Probability64 = -math.Expm1(-est.ExpectedPairs64)
+
+	return est
+}
+
+// expectedPairs returns n choose 2 divided by 2^bits; the expected number of
+// colliding pairs among n random values of the given number of bits.
+func expectedPairs(n uint, bits uint) float64 {
+	var pairs = float64(n) * (float64(n) - 1) / 2
+	return pairs / math.Exp2(float64(bits))
+}
@@ -0,0 +1,43 @@
+package hamt_test
+
+import (
+	"testing"
+
+	"github.com/lleo/go-hamt"
+)
+
+func TestEstimateCollisions(t *testing.T) {
+	var keys = make([][]byte, 0, 64*1024+1)
+	for _, kv := range KVS[:64*1024] {
+		keys = append(keys, []byte(kv.Key))
+	}
+	keys = append(keys, keys[0]) // duplicates are ignored
+
+	var est = hamt.EstimateCollisions(keys)
+
+	if est.Keys != 64*1024 {
+		t.Fatalf("TestEstimateCollisions: est.Keys,%d != %d", est.Keys, 64*1024)
+	}
+	if est.Colliding32 < 2*est.Groups32 || est.Colliding64 < 2*est.Groups64 {
+		t.Fatalf("TestEstimateCollisions: inconsistent collision counts %+v", *est)
+	}
+
+	// 64K keys over 30 bits: ~2 expected colliding pairs; over 60 bits: ~0.
+	if est.ExpectedPairs32 < 1.9 || est.ExpectedPairs32 > 2.1 {
+		t.Fatalf("TestEstimateCollisions: est.ExpectedPairs32,%g not ~2",
+			est.ExpectedPairs32)
+	}
+	if est.Probability32 < 0.8 || est.Probability32 >= 1 {
+		t.Fatalf("TestEstimateCollisions: est.Probability32,%g not ~0.86",
+			est.Probability32)
+	}
+	if est.Probability64 <= 0 || est.Probability64 > 1e-8 {
+		t.Fatalf("TestEstimateCollisions: est.Probability64,%g not ~0",
+			est.Probability64)
+	}
+
+	var none = hamt.EstimateCollisions(nil)
+	if none.Keys != 0 || none.Probability32 != 0 || none.ExpectedPairs64 != 0 {
+		t.Fatalf("TestEstimateCollisions: empty sample gave %+v", *none)
+	}
+}
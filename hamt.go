@@ -28,6 +28,11 @@ feature of the other implmentations I was looking at).
 While 32bit FNV hash values are still pretty random I have seen plenty of
 collisions in my tests.
 
+EstimateCollisions() measures this for your own keys; it counts the keys that
+collide under hamt32 and hamt64 hashing and compares them to the birthday
+bound for 30 and 60 bit hash values. For a built Hamt, the Collisions field of
+Stats() reports every collisionLeaf.
+
 I have never seen 64bit FNV hash values collide and in the current state of
 computing having 64bit CPUs as the norm. I recommend using hamt64. If you are
 on 32bit CPUs then maybe you could choose hamt32.
@@ -135,14 +140,17 @@ const (
 	// This was intended just save space, but also seems to be faster; CPU cache
 	// locality maybe?
 	SparseTables
+	// CompactTables indicates the structure should use the CHAMP node layout
+	// of HamtCompact, which stores KeyVal pairs inline rather than in leafs.
+	CompactTables
 )
 
 // TableOptionName is a lookup table to map the integer value of
-// FixedTables, SparseTables, and HybridTables to a string representing
-// that option.
+// FixedTables, SparseTables, HybridTables, and CompactTables to a string
+// representing that option.
 //     var option = hamt32.FixedTables
 //     hamt32.TableOptionName[option] == "FixedTables"
-var TableOptionName [3]string
+var TableOptionName [4]string
 
 // Could have used...
 //var TableOptionName = [3]string{
@@ -155,6 +163,7 @@ func init() {
 	TableOptionName[FixedTables] = "FixedTables"
 	TableOptionName[SparseTables] = "SparseTables"
 	TableOptionName[HybridTables] = "HybridTables"
+	TableOptionName[CompactTables] = "CompactTables"
 }
 
 // New() makes all the configuration choices for you. Specifically, it chooses
@@ -171,7 +180,7 @@ func New() hamt64.Hamt {
 // HamtTransient data structure.
 //
 // The tblOpt argument is the table option defined by the constants
-// HybridTables, SparseTables, xor FixedTables.
+// HybridTables, SparseTables, FixedTables, xor CompactTables.
 //
 func New32(functional bool, opt int) hamt32.Hamt {
 	return hamt32.New(functional, opt)
@@ -184,7 +193,7 @@ func New32(functional bool, opt int) hamt32.Hamt {
 // HamtTransient data structure.
 //
 // The tblOpt argument is the table option defined by the constants
-// HybridTables, SparseTables, xor FixedTables.
+// HybridTables, SparseTables, FixedTables, xor CompactTables.
 //
 func New64(functional bool, opt int) hamt64.Hamt {
 	return hamt64.New(functional, opt)
@@ -0,0 +1,7 @@
+package hamt32
+
+// Every file of hamt32, other than this one and hashwidth.go, is generated
+// from hamt64. Make changes to hamt64 and run go generate here.
+
+//go:generate go run ../internal/hamtgen ../hamt64 .
+//go:generate go run ../internal/hamtgen ../hamt64_test.go ../hamt32_test.go
@@ -0,0 +1,51 @@
+package hamt32
+
+// This file holds everything that depends on the width of HashVal. The rest
+// of the package is the same for hamt32 and hamt64, and is generated from
+// hamt64; see internal/hamtgen.
+
+// HashVal sets the numberer of bits of the hash value by being an alias to
+// uint32 and establishes a type we can hang methods, like Index(), off of.
+type HashVal uint32
+
+// CalcHash deterministically calculates a randomized uint32 of a given byte
+// slice . It is the FNV-1 hash of hash/fnv.New32() folded down to
+// DepthLimit*NumIndexBits bits, calculated without any allocations.
+func CalcHash(bs []byte) HashVal {
+	return newHashState().addBytes(bs).sum()
+}
+
+// hasherName is the Hasher of a Config.
+const hasherName = "FNV-1 32"
+
+// The FNV-1 parameters used by hash/fnv.New32().
+const (
+	fnvOffset32 uint32 = 2166136261
+	fnvPrime32  uint32 = 16777619
+)
+
+// hashState is the running state of an inline FNV-1 hash. It computes the
+// same hash as hash/fnv.New32() without allocating a hasher, and lets strings
+// be hashed without converting them to a byte slice.
+type hashState uint32
+
+func newHashState() hashState {
+	return hashState(fnvOffset32)
+}
+
+func (h hashState) addByte(b byte) hashState {
+	return (h * hashState(fnvPrime32)) ^ hashState(b)
+}
+
+// sum folds the hash state down to a HashVal.
+func (h hashState) sum() HashVal {
+	return HashVal(fold(uint32(h), remainder))
+}
+
+func mask(size uint) uint32 {
+	return uint32(1<<size) - 1
+}
+
+func fold(hash uint32, rem uint) uint32 {
+	return (hash >> (hashSize - rem)) ^ (hash & mask(hashSize-rem))
+}
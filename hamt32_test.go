@@ -1,7 +1,10 @@
+// Code generated by hamtgen from hamt64_test.go; DO NOT EDIT.
+
 package hamt_test
 
 import (
 	"log"
+	"math/rand"
 	"testing"
 	"time"
 
@@ -29,10 +32,86 @@ func TestBuild32(t *testing.T) {
 			t.Fatalf("%s: failed to insert s=%q, v=%d", name, k, v)
 		}
 
+		var err = h.Validate()
+		if err != nil {
+			log.Printf("%s: Validate() failed after inserting s=%q: %s", name, k, err)
+			t.Fatalf("%s: Validate() failed after inserting s=%q: %s", name, k, err)
+		}
+
 		//log.Print(h.LongString(""))
 	}
 }
 
+func TestHamt32Validate(t *testing.T) {
+	runTestHamt32Validate(t, KVS[:ValidateNumKvs], Functional, TableOption)
+}
+
+// runTestHamt32Validate Puts every KeyVal of kvs into a new Hamt, then Dels
+// them all in a random order, calling Validate() after every mutation.
+func runTestHamt32Validate(
+	t *testing.T,
+	kvs []KeyVal,
+	functional bool,
+	tblOpt int,
+) {
+	var name = "TestHamt32Validate"
+	if functional {
+		name += ":functional:" + hamt32.TableOptionName[tblOpt]
+	} else {
+		name += ":transient:" + hamt32.TableOptionName[tblOpt]
+	}
+
+	StartTime[name] = time.Now()
+	var h = hamt32.New(functional, tblOpt)
+	for _, kv := range kvs {
+		var k = hamt32.StringKey(kv.Key)
+
+		var inserted bool
+		h, inserted = h.Put(k, kv.Val)
+		if !inserted {
+			t.Fatalf("%s: failed to h.Put(%q, %v)", name, k, kv.Val)
+		}
+
+		var err = h.Validate()
+		if err != nil {
+			log.Printf("%s: Validate() failed after h.Put(%q, %v): %s",
+				name, k, kv.Val, err)
+			t.Fatalf("%s: Validate() failed after h.Put(%q, %v): %s",
+				name, k, kv.Val, err)
+		}
+	}
+
+	var rnd = rand.New(rand.NewSource(int64(len(kvs))))
+	for _, i := range rnd.Perm(len(kvs)) {
+		var k = hamt32.StringKey(kvs[i].Key)
+
+		var val interface{}
+		var deleted bool
+		h, val, deleted = h.Del(k)
+		if !deleted {
+			t.Fatalf("%s: failed to h.Del(%q)", name, k)
+		}
+		if val != kvs[i].Val {
+			t.Fatalf("%s: h.Del(%q) returned val,%v != expected,%v",
+				name, k, val, kvs[i].Val)
+		}
+
+		var err = h.Validate()
+		if err != nil {
+			log.Printf("%s: Validate() failed after h.Del(%q): %s",
+				name, k, err)
+			t.Fatalf("%s: Validate() failed after h.Del(%q): %s",
+				name, k, err)
+		}
+	}
+
+	if !h.IsEmpty() {
+		t.Fatalf("%s: h.Nentries(),%d != 0 after deleting every key",
+			name, h.Nentries())
+	}
+	RunTime[name] = time.Since(StartTime[name])
+}
+
 func TestHamt32Put(t *testing.T) {
 	runTestHamt32Put(t, KVS, Functional, TableOption)
 }
@@ -52,7 +131,7 @@ func runTestHamt32Put(
 
 	StartTime[name] = time.Now()
 	Hamt32 = hamt32.New(functional, tblOpt)
-	for _, kv := range kvs {
+	for i, kv := range kvs {
 		var k = hamt32.StringKey(kv.Key)
 		var v = kv.Val
 
@@ -63,6 +142,8 @@ func runTestHamt32Put(
 			t.Fatalf("%s: failed to Hamt32.Put(%q, %v)", name, k, v)
 		}
 
+		validateHamt(t, name, Hamt32, i)
+
 		var val, found = Hamt32.Get(k)
 		if !found {
 			log.Printf("%s: failed to Hamt32.Get(%q)", name, k)
@@ -76,6 +157,12 @@ func runTestHamt32Put(
 	}
 	RunTime[name] = time.Since(StartTime[name])
 
+	var err = Hamt32.Validate()
+	if err != nil {
+		log.Printf("%s: Validate() failed on full Hamt32: %s", name, err)
+		t.Fatalf("%s: Validate() failed on full Hamt32: %s", name, err)
+	}
+
 	StartTime["Hamt32.Stats()"] = time.Now()
 	var stats = Hamt32.Stats()
 	RunTime["Hamt32.Stats()"] = time.Since(StartTime["Hamt32.Stats()"])
@@ -242,7 +329,7 @@ func runTestHamt32Del(
 	}
 
 	StartTime[name] = time.Now()
-	for _, kv := range kvs {
+	for i, kv := range kvs {
 		var k = hamt32.StringKey(kv.Key)
 		var v = kv.Val
 
@@ -258,6 +345,14 @@ func runTestHamt32Del(
 			log.Printf("%s: retrieved val,%d != expected v,%d for s=%q", name, val, v, k)
 			t.Fatalf("%s: retrieved val,%d != expected v,%d for s=%q", name, val, v, k)
 		}
+
+		validateHamt(t, name, Hamt32, i)
+	}
+
+	var err = Hamt32.Validate()
+	if err != nil {
+		log.Printf("%s: Validate() failed on emptied Hamt32: %s", name, err)
+		t.Fatalf("%s: Validate() failed on emptied Hamt32: %s", name, err)
 	}
 	RunTime[name] = time.Since(StartTime[name])
 }
@@ -76,3 +76,12 @@ func (bm *bitmap) Count(idx uint) uint {
 
 	return count
 }
+
+// PopCount returns the total number of bits set in the bitmap.
+func (bm *bitmap) PopCount() uint {
+	var count uint
+	for i := uint(0); i < bitmapSize; i++ {
+		count += bitCount32(bm[i])
+	}
+	return count
+}
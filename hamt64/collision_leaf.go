@@ -7,12 +7,15 @@ import (
 
 // implements nodeI
 // implements leafI
+// collisionLeaf stores the HashVal shared by all of its keys, hv.
 type collisionLeaf struct {
+	hv  HashVal
 	kvs []KeyVal
 }
 
-func newCollisionLeaf(kvs []KeyVal) *collisionLeaf {
+func newCollisionLeaf(hv HashVal, kvs []KeyVal) *collisionLeaf {
 	var leaf = new(collisionLeaf)
+	leaf.hv = hv
 	leaf.kvs = append(leaf.kvs, kvs...)
 
 	//log.Println("newCollisionLeaf:", leaf)
@@ -22,12 +25,13 @@ func newCollisionLeaf(kvs []KeyVal) *collisionLeaf {
 
 func (l *collisionLeaf) copy() *collisionLeaf {
 	var nl = new(collisionLeaf)
+	nl.hv = l.hv
 	nl.kvs = append(nl.kvs, l.kvs...)
 	return nl
 }
 
 func (l *collisionLeaf) Hash() HashVal {
-	return l.kvs[0].Key.Hash()
+	return l.hv
 }
 
 func (l *collisionLeaf) String() string {
@@ -38,7 +42,7 @@ func (l *collisionLeaf) String() string {
 	var jkvstr = strings.Join(kvstrs, ",")
 
 	return fmt.Sprintf("collisionLeaf{hash:%s, kvs:[]KeyVal{%s}}",
-		l.kvs[0].Key.Hash(), jkvstr)
+		l.hv, jkvstr)
 }
 
 func (l *collisionLeaf) get(key KeyI) (interface{}, bool) {
@@ -59,6 +63,7 @@ func (l *collisionLeaf) put(key KeyI, val interface{}) (leafI, bool) {
 		}
 	}
 	var nl = new(collisionLeaf)
+	nl.hv = l.hv
 	nl.kvs = make([]KeyVal, len(l.kvs)+1)
 	copy(nl.kvs, l.kvs)
 	nl.kvs[len(l.kvs)] = KeyVal{key, val}
@@ -75,7 +80,7 @@ func (l *collisionLeaf) del(key KeyI) (leafI, interface{}, bool) {
 			var nl leafI
 			if len(l.kvs) == 2 {
 				// think about the index... it works, really :)
-				nl = newFlatLeaf(l.kvs[1-i].Key, l.kvs[1-i].Val)
+				nl = newFlatLeaf(l.hv, l.kvs[1-i].Key, l.kvs[1-i].Val)
 			} else {
 				var cl = l.copy()
 				cl.kvs = append(cl.kvs[:i], cl.kvs[i+1:]...)
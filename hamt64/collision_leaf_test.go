@@ -0,0 +1,205 @@
+package hamt64_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/lleo/go-hamt/hamt64"
+)
+
+// TestHamt64HeavyCollisions runs Put, Get, Range, Stats, and Del over keys
+// whose HashVals differ only in the index of one depth, so they are stored in
+// collisionLeafs at that depth; for every depth from the root to maxDepth.
+func TestHamt64HeavyCollisions(t *testing.T) {
+	var numKeys = 1000
+
+	for _, tblOpt := range []int{TableOption, hamt64.CompactTables} {
+		for depth := uint(0); depth < hamt64.DepthLimit; depth++ {
+			var name = fmt.Sprintf("TestHamt64HeavyCollisions:%s:depth=%d",
+				hamt64.TableOptionName[tblOpt], depth)
+
+			var keys = make([]truncatedKey, numKeys)
+			for i := range keys {
+				keys[i] = truncatedKey{fmt.Sprintf("key%d", i), indexMask(depth)}
+			}
+
+			runHeavyCollisions(t, name, hamt64.New(Functional, tblOpt), keys,
+				depth)
+		}
+	}
+}
+
+func runHeavyCollisions(
+	t *testing.T,
+	name string,
+	h hamt64.Hamt,
+	keys []truncatedKey,
+	depth uint,
+) {
+	for i, k := range keys {
+		var added bool
+		h, added = h.Put(k, i)
+		if !added {
+			t.Fatalf("%s: Put(%s) did not add it", name, k)
+		}
+	}
+	var err = h.Validate()
+	if err != nil {
+		t.Fatalf("%s: Validate() failed: %s", name, err)
+	}
+
+	var stats = h.Stats()
+	if stats.KeyVals != uint(len(keys)) {
+		t.Fatalf("%s: Stats().KeyVals,%d != %d",
+			name, stats.KeyVals, len(keys))
+	}
+	// The keys have only IndexLimit distinct HashVals, so nearly all of them
+	// collide; in collisionLeafs in the table at depth, or, for a
+	// HamtCompact, in collision nodes below the deepest table.
+	if stats.CollisionLeafs == 0 {
+		t.Fatalf("%s: Stats().CollisionLeafs == 0", name)
+	}
+	if _, isCompact := h.(*hamt64.HamtCompact); !isCompact &&
+		stats.MaxDepth != depth {
+		t.Fatalf("%s: Stats().MaxDepth,%d != %d", name, stats.MaxDepth, depth)
+	}
+
+	var seen = make(map[string]bool)
+	h.Range(func(k hamt64.KeyI, v interface{}) bool {
+		seen[k.(truncatedKey).s] = true
+		return true
+	})
+	if len(seen) != len(keys) {
+		t.Fatalf("%s: Range visited %d keys; expected %d",
+			name, len(seen), len(keys))
+	}
+
+	// Delete every other key, then the rest, checking the survivors as the
+	// collisionLeafs shrink to flatLeafs.
+	for pass := 0; pass < 2; pass++ {
+		for i := pass; i < len(keys); i += 2 {
+			var val interface{}
+			var deleted bool
+			h, val, deleted = h.Del(keys[i])
+			if !deleted || val != i {
+				t.Fatalf("%s: Del(%s) = %v, %t; expected %d, true",
+					name, keys[i], val, deleted, i)
+			}
+		}
+		err = h.Validate()
+		if err != nil {
+			t.Fatalf("%s: Validate() failed after deletion pass %d: %s",
+				name, pass, err)
+		}
+		for i := 1 - pass; pass == 0 && i < len(keys); i += 2 {
+			var val, found = h.Get(keys[i])
+			if !found || val != i {
+				t.Fatalf("%s: Get(%s) = %v, %t; expected %d, true",
+					name, keys[i], val, found, i)
+			}
+		}
+	}
+
+	// Every table below the root must have been removed with its last key.
+	stats = h.Stats()
+	if !h.IsEmpty() || stats.Leafs != 0 || stats.Tables != 1 {
+		t.Fatalf("%s: empty Hamt has %d tables and %d leafs",
+			name, stats.Tables, stats.Leafs)
+	}
+}
+
+// TestHamt64CollisionLeafDel checks that deleting from a two key
+// collisionLeaf at maxDepth leaves a flatLeaf in its place, and that deleting
+// the last key below the root leaves no tables behind.
+func TestHamt64CollisionLeafDel(t *testing.T) {
+	var name = "TestHamt64CollisionLeafDel"
+
+	// The three keys differ only in the index at maxDepth, and only other
+	// differs from k1 and k2 at all.
+	var shift = (hamt64.DepthLimit - 1) * hamt64.NumIndexBits
+	var hv = hamt64.HashVal(1) << shift
+	var k1, k2 = fixedHashKey{"k1", hv}, fixedHashKey{"k2", hv}
+	var other = fixedHashKey{"other", hamt64.HashVal(2) << shift}
+
+	var h = hamt64.New(Functional, TableOption)
+	h, _ = h.Put(other, 0)
+	h, _ = h.Put(k1, 1)
+	h, _ = h.Put(k2, 2)
+
+	var leaf = findLeaf(t, h, "k1")
+	if leaf.Type != "collisionLeaf" || leaf.Depth != hamt64.DepthLimit-1 {
+		t.Fatalf("%s: k1 and k2 are in a %s at depth %d; expected a "+
+			"collisionLeaf at maxDepth", name, leaf.Type, leaf.Depth)
+	}
+
+	var before = h
+	var val interface{}
+	var deleted bool
+	h, val, deleted = h.Del(k1)
+	if !deleted || val != 1 {
+		t.Fatalf("%s: Del(k1) = %v, %t; expected 1, true", name, val, deleted)
+	}
+	var err = h.Validate()
+	if err != nil {
+		t.Fatalf("%s: Validate() failed: %s", name, err)
+	}
+
+	leaf = findLeaf(t, h, "k2")
+	if leaf.Type != "flatLeaf" || leaf.Depth != hamt64.DepthLimit-1 {
+		t.Fatalf("%s: after Del(k1), k2 is in a %s at depth %d; expected a "+
+			"flatLeaf at maxDepth", name, leaf.Type, leaf.Depth)
+	}
+	if s := h.Stats(); s.CollisionLeafs != 0 || s.FlatLeafs != 2 {
+		t.Fatalf("%s: after Del(k1), Stats() has %d collisionLeafs and %d "+
+			"flatLeafs", name, s.CollisionLeafs, s.FlatLeafs)
+	}
+
+	if Functional {
+		if leaf = findLeaf(t, before, "k1"); leaf.Type != "collisionLeaf" {
+			t.Fatalf("%s: Del(k1) modified the previous version", name)
+		}
+	}
+
+	for _, k := range []hamt64.KeyI{k2, other} {
+		h, _, deleted = h.Del(k)
+		if !deleted {
+			t.Fatalf("%s: Del(%s) failed", name, k)
+		}
+	}
+	// Only the root remains; the chain of tables down to maxDepth is gone.
+	if s := h.Stats(); s.Tables != 1 || s.Leafs != 0 {
+		t.Fatalf("%s: after deleting every key, Stats() has %d tables and "+
+			"%d leafs; expected 1 and 0", name, s.Tables, s.Leafs)
+	}
+}
+
+// findLeaf returns the NodeDump of the leaf of h holding the key whose string
+// form is key.
+func findLeaf(t *testing.T, h hamt64.Hamt, key string) *hamt64.NodeDump {
+	var d, err = hamt64.Dump(h, &hamt64.ExportOptions{KeyVals: true})
+	if err != nil {
+		t.Fatalf("Dump failed: %s", err)
+	}
+
+	var find func(n *hamt64.NodeDump) *hamt64.NodeDump
+	find = func(n *hamt64.NodeDump) *hamt64.NodeDump {
+		for _, kv := range n.KeyVals {
+			if strings.HasPrefix(kv, fmt.Sprintf("{%q,", key)) {
+				return n
+			}
+		}
+		for _, c := range n.Children {
+			if l := find(c); l != nil {
+				return l
+			}
+		}
+		return nil
+	}
+
+	var leaf = find(d)
+	if leaf == nil {
+		t.Fatalf("no leaf holds %s", key)
+	}
+	return leaf
+}
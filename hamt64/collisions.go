@@ -0,0 +1,94 @@
+package hamt64
+
+import (
+	"fmt"
+	"sort"
+)
+
+// MaxWorstCollisions is the maximum number of collisionLeafs listed in
+// CollisionReport.Worst.
+const MaxWorstCollisions = 10
+
+// CollisionReport summarizes the hash collisions found in a Hamt. Every
+// collisionLeaf holds two or more keys with the same HashVal.
+type CollisionReport struct {
+	// CollisionLeafs is the total count of collisionLeaf structs in the HAMT.
+	CollisionLeafs uint
+
+	// KeyVals is the total number of KeyVal pairs stored in collisionLeafs.
+	KeyVals uint
+
+	// SizeCounts is a histogram of collisionLeaf sizes; it maps the number of
+	// KeyVal pairs in a collisionLeaf to the number of collisionLeafs of that
+	// size.
+	SizeCounts map[int]uint
+
+	// Worst lists up to MaxWorstCollisions of the largest collisionLeafs,
+	// largest first.
+	Worst []Collision
+
+	// AboveMaxDepth is the number of collisionLeafs stored in a table above
+	// maxDepth. Put stores a collisionLeaf wherever the colliding flatLeaf
+	// was, rather than pushing it down to maxDepth.
+	AboveMaxDepth uint
+}
+
+// Collision describes one collisionLeaf.
+type Collision struct {
+	// Hash is the HashVal shared by all the Keys.
+	Hash HashVal
+
+	// Depth is the depth of the table the collisionLeaf is stored in.
+	Depth uint
+
+	// Keys are the colliding keys.
+	Keys []KeyI
+}
+
+func (c Collision) String() string {
+	return fmt.Sprintf("Collision{Hash:%s, Depth:%d, Keys:%v}",
+		c.Hash, c.Depth, c.Keys)
+}
+
+// collect adds every collisionLeaf in the table t, located at depth,
+// and the tables below it to the CollisionReport r. It does not sort or trim
+// r.Worst; see finish().
+func (r *CollisionReport) collect(t tableI, depth uint) {
+	for _, ent := range t.entries() {
+		switch x := ent.node.(type) {
+		case tableI:
+			r.collect(x, depth+1)
+		case *collisionLeaf:
+			r.CollisionLeafs++
+			r.KeyVals += uint(len(x.kvs))
+			if r.SizeCounts == nil {
+				r.SizeCounts = make(map[int]uint)
+			}
+			r.SizeCounts[len(x.kvs)]++
+			if depth < maxDepth {
+				r.AboveMaxDepth++
+			}
+
+			var keys = make([]KeyI, len(x.kvs))
+			for i, kv := range x.kvs {
+				keys[i] = kv.Key
+			}
+			r.Worst = append(r.Worst,
+				Collision{Hash: x.Hash(), Depth: depth, Keys: keys})
+		}
+	}
+}
+
+// finish orders r.Worst largest first, breaking ties by HashVal, and trims it
+// to MaxWorstCollisions entries.
+func (r *CollisionReport) finish() {
+	sort.Slice(r.Worst, func(i, j int) bool {
+		if len(r.Worst[i].Keys) != len(r.Worst[j].Keys) {
+			return len(r.Worst[i].Keys) > len(r.Worst[j].Keys)
+		}
+		return r.Worst[i].Hash < r.Worst[j].Hash
+	})
+	if len(r.Worst) > MaxWorstCollisions {
+		r.Worst = r.Worst[:MaxWorstCollisions]
+	}
+}
@@ -0,0 +1,127 @@
+package hamt64_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/lleo/go-hamt/hamt64"
+)
+
+// fixedHashKey is a key with an arbitrary, caller chosen, HashVal. It lets the
+// tests create hash collisions on demand.
+type fixedHashKey struct {
+	s  string
+	hv hamt64.HashVal
+}
+
+func (k fixedHashKey) Hash() hamt64.HashVal {
+	return k.hv
+}
+
+func (k fixedHashKey) Equals(other hamt64.KeyI) bool {
+	var o, ok = other.(fixedHashKey)
+	return ok && o.s == k.s
+}
+
+func (k fixedHashKey) String() string {
+	return k.s
+}
+
+// truncatedKey is a StringKey whose HashVal is masked by mask, so keys
+// collide as often as the caller likes. With mask set to indexMask(depth)
+// every key has the same index at every depth but depth; the Hamt is a chain
+// of tables down to a table at depth full of collisionLeafs.
+type truncatedKey struct {
+	s    string
+	mask hamt64.HashVal
+}
+
+func (k truncatedKey) Hash() hamt64.HashVal {
+	return hamt64.StringKey(k.s).Hash() & k.mask
+}
+
+func (k truncatedKey) Equals(other hamt64.KeyI) bool {
+	var o, ok = other.(truncatedKey)
+	return ok && o.s == k.s
+}
+
+func (k truncatedKey) String() string {
+	return k.s
+}
+
+// indexMask returns the mask of the bits of a HashVal indexing the table at
+// depth.
+func indexMask(depth uint) hamt64.HashVal {
+	return hamt64.HashVal(hamt64.IndexLimit-1) << (depth * hamt64.NumIndexBits)
+}
+
+func TestHamt64CollisionReport(t *testing.T) {
+	var name = "TestHamt64CollisionReport"
+
+	var h = hamt64.New(Functional, TableOption)
+
+	// groups[i] keys share the HashVal i+1; group 0 has no collisions.
+	var groups = []int{1, 2, 2, 3, 5}
+	var nkvs int
+	for i, n := range groups {
+		for j := 0; j < n; j++ {
+			var k = fixedHashKey{fmt.Sprintf("k%d.%d", i, j), hamt64.HashVal(i + 1)}
+			h, _ = h.Put(k, nkvs)
+			nkvs++
+		}
+	}
+	for _, kv := range KVS64[:1000] {
+		h, _ = h.Put(kv.Key, kv.Val)
+	}
+
+	var err = h.Validate()
+	if err != nil {
+		t.Fatalf("%s: Validate() failed: %s", name, err)
+	}
+
+	var r = h.Stats().Collisions
+
+	if r.CollisionLeafs != 4 {
+		t.Fatalf("%s: r.CollisionLeafs,%d != 4", name, r.CollisionLeafs)
+	}
+	if r.KeyVals != 2+2+3+5 {
+		t.Fatalf("%s: r.KeyVals,%d != 12", name, r.KeyVals)
+	}
+	if r.SizeCounts[2] != 2 || r.SizeCounts[3] != 1 || r.SizeCounts[5] != 1 {
+		t.Fatalf("%s: wrong r.SizeCounts %v", name, r.SizeCounts)
+	}
+	if len(r.Worst) != 4 {
+		t.Fatalf("%s: len(r.Worst),%d != 4", name, len(r.Worst))
+	}
+	if r.Worst[0].Hash != 5 || len(r.Worst[0].Keys) != 5 {
+		t.Fatalf("%s: r.Worst[0] = %s", name, r.Worst[0])
+	}
+	if r.Worst[1].Hash != 4 || r.Worst[2].Hash != 2 || r.Worst[3].Hash != 3 {
+		t.Fatalf("%s: r.Worst not ordered: %v", name, r.Worst)
+	}
+
+	// Every collisionLeaf was created on top of a flatLeaf in a shallow
+	// table, so none of them were pushed down to maxDepth.
+	if r.AboveMaxDepth != r.CollisionLeafs {
+		t.Fatalf("%s: r.AboveMaxDepth,%d != r.CollisionLeafs,%d",
+			name, r.AboveMaxDepth, r.CollisionLeafs)
+	}
+	for _, c := range r.Worst {
+		if c.Depth >= hamt64.DepthLimit-1 {
+			t.Fatalf("%s: collision %s at maxDepth", name, c)
+		}
+	}
+}
+
+func TestHamt64CollisionReportNone(t *testing.T) {
+	var h, err = buildHamt64("TestHamt64CollisionReportNone", KVS64[:10000],
+		Functional, TableOption)
+	if err != nil {
+		t.Fatalf("TestHamt64CollisionReportNone: buildHamt64 failed: %s", err)
+	}
+
+	var r = h.Stats().Collisions
+	if r.CollisionLeafs != 0 || r.KeyVals != 0 || len(r.Worst) != 0 {
+		t.Fatalf("TestHamt64CollisionReportNone: unexpected collisions %+v", r)
+	}
+}
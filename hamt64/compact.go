@@ -0,0 +1,606 @@
+package hamt64
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// HamtCompact is a Hamt with the CHAMP ("Compressed Hash-Array Mapped Prefix
+// tree") node layout, constructed by NewCompact or by New with the
+// CompactTables option.
+//
+// Each node has two bitmaps; one for the indexes holding a KeyVal pair and one
+// for the indexes holding a sub-node. The KeyVal pairs are stored inline, in a
+// slice of the node, rather than in a leaf of their own. A HamtCompact of n
+// entries is therefore roughly n/IndexLimit heap objects, rather than the
+// more than n of the other table options, which is far less for the garbage
+// collector to scan. Keys with the same HashVal are kept in a collision node
+// below the deepest table.
+//
+// Nodes are kept in canonical form: a node other than the root never holds
+// only a single KeyVal pair, it is inlined into its parent instead.
+//
+// Like HamtFunctional and HamtTransient, a HamtCompact is either functional
+// (copy-on-write) or transient (modified in place); ToFunctional and
+// ToTransient switch between the two without copying, so the same cautions
+// apply.
+//
+// HamtCompact implements the Hamt interface, but the functions that work on
+// the tables of a HamtFunctional or HamtTransient, such as SubTrie, Join,
+// WriteImage, and WriteDot, do not accept it.
+type HamtCompact struct {
+	root      *champNode
+	nentries  uint
+	transient bool
+}
+
+// champNode is a node of a HamtCompact. A node at depth DepthLimit is a
+// collision node; its bitmaps are unused and kvs holds every KeyVal pair with
+// the node's HashVal.
+type champNode struct {
+	dataMap uint32
+	nodeMap uint32
+	kvs     []champEntry // in index order
+	nodes   []*champNode // in index order
+}
+
+type champEntry struct {
+	hv  HashVal
+	key KeyI
+	val interface{}
+}
+
+// NewCompact constructs a new, empty HamtCompact. It is functional if the
+// functional argument is true, else transient.
+func NewCompact(functional bool) *HamtCompact {
+	return &HamtCompact{root: new(champNode), transient: !functional}
+}
+
+func (n *champNode) dataIndex(bit uint32) int {
+	return int(bitCount32(n.dataMap & (bit - 1)))
+}
+
+func (n *champNode) nodeIndex(bit uint32) int {
+	return int(bitCount32(n.nodeMap & (bit - 1)))
+}
+
+// clone returns a copy of n that shares no slices with it.
+func (n *champNode) clone() *champNode {
+	var nn = &champNode{dataMap: n.dataMap, nodeMap: n.nodeMap}
+	if len(n.kvs) > 0 {
+		nn.kvs = make([]champEntry, len(n.kvs))
+		copy(nn.kvs, n.kvs)
+	}
+	if len(n.nodes) > 0 {
+		nn.nodes = make([]*champNode, len(n.nodes))
+		copy(nn.nodes, n.nodes)
+	}
+	return nn
+}
+
+func (n *champNode) deepCopy() *champNode {
+	var nn = n.clone()
+	for i, child := range nn.nodes {
+		nn.nodes[i] = child.deepCopy()
+	}
+	return nn
+}
+
+func (n *champNode) insertData(bit uint32, e champEntry) {
+	var i = n.dataIndex(bit)
+	n.kvs = append(n.kvs, champEntry{})
+	copy(n.kvs[i+1:], n.kvs[i:])
+	n.kvs[i] = e
+	n.dataMap |= bit
+}
+
+func (n *champNode) removeData(bit uint32) {
+	n.kvs = removeEntry(n.kvs, n.dataIndex(bit))
+	n.dataMap &^= bit
+}
+
+func (n *champNode) insertNode(bit uint32, child *champNode) {
+	var i = n.nodeIndex(bit)
+	n.nodes = append(n.nodes, nil)
+	copy(n.nodes[i+1:], n.nodes[i:])
+	n.nodes[i] = child
+	n.nodeMap |= bit
+}
+
+func (n *champNode) removeNode(bit uint32) {
+	var i = n.nodeIndex(bit)
+	copy(n.nodes[i:], n.nodes[i+1:])
+	n.nodes[len(n.nodes)-1] = nil
+	n.nodes = n.nodes[:len(n.nodes)-1]
+	n.nodeMap &^= bit
+}
+
+func removeEntry(kvs []champEntry, i int) []champEntry {
+	copy(kvs[i:], kvs[i+1:])
+	kvs[len(kvs)-1] = champEntry{}
+	return kvs[:len(kvs)-1]
+}
+
+// newChampPair returns a node at depth holding the entries e1 and e2, which
+// have different keys.
+func newChampPair(depth uint, e1, e2 champEntry) *champNode {
+	var n = new(champNode)
+	if depth == DepthLimit {
+		n.kvs = []champEntry{e1, e2}
+		return n
+	}
+
+	var i1, i2 = e1.hv.Index(depth), e2.hv.Index(depth)
+	if i1 == i2 {
+		n.nodeMap = 1 << i1
+		n.nodes = []*champNode{newChampPair(depth+1, e1, e2)}
+		return n
+	}
+
+	n.dataMap = 1<<i1 | 1<<i2
+	if i1 < i2 {
+		n.kvs = []champEntry{e1, e2}
+	} else {
+		n.kvs = []champEntry{e2, e1}
+	}
+	return n
+}
+
+// own returns n if h is transient, else a copy of n that may be modified.
+func (h *HamtCompact) own(n *champNode) *champNode {
+	if h.transient {
+		return n
+	}
+	return n.clone()
+}
+
+// IsEmpty simply returns if the HamtCompact data structure has no entries.
+func (h *HamtCompact) IsEmpty() bool {
+	return h.nentries == 0
+}
+
+// Nentries return the number of (key,value) pairs are stored in the
+// HamtCompact data structure.
+func (h *HamtCompact) Nentries() uint {
+	return h.nentries
+}
+
+// ToFunctional returns a functional HamtCompact sharing every node with h.
+func (h *HamtCompact) ToFunctional() Hamt {
+	return &HamtCompact{root: h.root, nentries: h.nentries}
+}
+
+// ToTransient returns a transient HamtCompact sharing every node with h.
+//
+// If you want a completely independent transient HamtCompact, you should first
+// do a DeepCopy followed by a ToTransient call.
+func (h *HamtCompact) ToTransient() Hamt {
+	return &HamtCompact{root: h.root, nentries: h.nentries, transient: true}
+}
+
+// DeepCopy copies the HamtCompact data structure and every node it contains
+// recursively.
+func (h *HamtCompact) DeepCopy() Hamt {
+	return &HamtCompact{
+		root:      h.root.deepCopy(),
+		nentries:  h.nentries,
+		transient: h.transient,
+	}
+}
+
+// Get retrieves the value related to the key in the HamtCompact data
+// structure. It also return a bool to indicate the value was found.
+func (h *HamtCompact) Get(key KeyI) (interface{}, bool) {
+	if h.IsEmpty() {
+		return nil, false
+	}
+
+	var hv HashVal
+	key, hv = hashKey(key)
+
+	var n = h.root
+	for depth := uint(0); depth < DepthLimit; depth++ {
+		var bit = uint32(1) << hv.Index(depth)
+		if n.dataMap&bit != 0 {
+			var e = &n.kvs[n.dataIndex(bit)]
+			if e.hv == hv && e.key.Equals(key) {
+				return e.val, true
+			}
+			return nil, false
+		}
+		if n.nodeMap&bit == 0 {
+			return nil, false
+		}
+		n = n.nodes[n.nodeIndex(bit)]
+	}
+
+	// n is a collision node
+	for i := range n.kvs {
+		if n.kvs[i].key.Equals(key) {
+			return n.kvs[i].val, true
+		}
+	}
+	return nil, false
+}
+
+// Put stores a new (key,value) pair in the HamtCompact data structure. It
+// returns a bool indicating if a new pair was added (true) or if the value
+// replaced (false). A functional HamtCompact returns a new HamtCompact
+// containing the modification; a transient one returns itself.
+func (h *HamtCompact) Put(key KeyI, val interface{}) (Hamt, bool) {
+	var hv HashVal
+	key, hv = hashKey(key)
+
+	var nh = h
+	if !h.transient {
+		nh = new(HamtCompact)
+		*nh = *h
+	}
+
+	var added bool
+	nh.root, added = nh.put(h.root, 0, champEntry{hv, key, val})
+	if added {
+		nh.nentries++
+	}
+
+	return nh, added
+}
+
+func (h *HamtCompact) put(n *champNode, depth uint, e champEntry) (*champNode, bool) {
+	n = h.own(n)
+
+	if depth == DepthLimit {
+		for i := range n.kvs {
+			if n.kvs[i].key.Equals(e.key) {
+				n.kvs[i] = e
+				return n, false
+			}
+		}
+		n.kvs = append(n.kvs, e)
+		return n, true
+	}
+
+	var bit = uint32(1) << e.hv.Index(depth)
+
+	if n.dataMap&bit != 0 {
+		var i = n.dataIndex(bit)
+		var cur = n.kvs[i]
+		if cur.hv == e.hv && cur.key.Equals(e.key) {
+			n.kvs[i] = e
+			return n, false
+		}
+		n.removeData(bit)
+		n.insertNode(bit, newChampPair(depth+1, cur, e))
+		return n, true
+	}
+
+	if n.nodeMap&bit != 0 {
+		var i = n.nodeIndex(bit)
+		var added bool
+		n.nodes[i], added = h.put(n.nodes[i], depth+1, e)
+		return n, added
+	}
+
+	n.insertData(bit, e)
+	return n, true
+}
+
+// Del searches the HamtCompact for the key argument and returns three values:
+// a Hamt, a value, and a bool.
+//
+// If the key was found, then the bool returned is true and the value is the
+// value related to that key. A functional HamtCompact returns a new
+// HamtCompact without the key; a transient one returns itself.
+//
+// If key was not found, then the bool returned is false, the value is nil, and
+// the Hamt is the original HamtCompact.
+func (h *HamtCompact) Del(key KeyI) (Hamt, interface{}, bool) {
+	if h.IsEmpty() {
+		return h, nil, false
+	}
+
+	var hv HashVal
+	key, hv = hashKey(key)
+
+	var root, val, deleted = h.del(h.root, 0, hv, key)
+	if !deleted {
+		return h, nil, false
+	}
+
+	var nh = h
+	if !h.transient {
+		nh = new(HamtCompact)
+		*nh = *h
+	}
+	nh.root = root
+	nh.nentries--
+
+	return nh, val, true
+}
+
+func (h *HamtCompact) del(
+	n *champNode,
+	depth uint,
+	hv HashVal,
+	key KeyI,
+) (*champNode, interface{}, bool) {
+	if depth == DepthLimit {
+		for i := range n.kvs {
+			if n.kvs[i].key.Equals(key) {
+				var val = n.kvs[i].val
+				n = h.own(n)
+				n.kvs = removeEntry(n.kvs, i)
+				return n, val, true
+			}
+		}
+		return n, nil, false
+	}
+
+	var bit = uint32(1) << hv.Index(depth)
+
+	if n.dataMap&bit != 0 {
+		var e = n.kvs[n.dataIndex(bit)]
+		if e.hv != hv || !e.key.Equals(key) {
+			return n, nil, false
+		}
+		n = h.own(n)
+		n.removeData(bit)
+		return n, e.val, true
+	}
+
+	if n.nodeMap&bit != 0 {
+		var i = n.nodeIndex(bit)
+		var child, val, deleted = h.del(n.nodes[i], depth+1, hv, key)
+		if !deleted {
+			return n, nil, false
+		}
+
+		n = h.own(n)
+		switch {
+		case len(child.kvs) == 0 && len(child.nodes) == 0:
+			n.removeNode(bit)
+		case len(child.kvs) == 1 && len(child.nodes) == 0:
+			// keep the trie canonical; inline the last KeyVal pair
+			n.removeNode(bit)
+			n.insertData(bit, child.kvs[0])
+		default:
+			n.nodes[i] = child
+		}
+		return n, val, true
+	}
+
+	return n, nil, false
+}
+
+// String returns a simple string representation of the HamtCompact data
+// structure.
+func (h *HamtCompact) String() string {
+	return fmt.Sprintf("HamtCompact{ %s, nentries: %d, root: %s }",
+		h.Config(), h.nentries, h.root.String())
+}
+
+func (n *champNode) String() string {
+	return fmt.Sprintf("champNode{ dataMap: %032b, nodeMap: %032b, "+
+		"kvs: %d, nodes: %d }", n.dataMap, n.nodeMap, len(n.kvs), len(n.nodes))
+}
+
+// LongString returns a complete recusive listing of the entire HamtCompact
+// data structure.
+func (h *HamtCompact) LongString(indent string) string {
+	var str = indent + fmt.Sprintf("HamtCompact{ %s, nentries: %d, root:\n",
+		h.Config(), h.nentries)
+	str += h.root.LongString(indent+"  ", 0)
+	str += indent + "} //HamtCompact"
+	return str
+}
+
+func (n *champNode) LongString(indent string, depth uint) string {
+	var strs = []string{indent + n.String()}
+	for _, e := range n.kvs {
+		strs = append(strs,
+			fmt.Sprintf("%s  %s: %v => %v", indent, e.hv.HashPathString(depth+1),
+				e.key, e.val))
+	}
+	var str = strings.Join(strs, "\n") + "\n"
+	for _, child := range n.nodes {
+		str += child.LongString(indent+"  ", depth+1)
+	}
+	return str
+}
+
+// rangeNode calls fn for every KeyVal pair in and below n. It returns false if
+// fn stopped the traversal.
+func (n *champNode) rangeNode(fn func(KeyI, interface{}) bool) bool {
+	for i := range n.kvs {
+		if !fn(n.kvs[i].key, n.kvs[i].val) {
+			return false
+		}
+	}
+	for _, child := range n.nodes {
+		if !child.rangeNode(fn) {
+			return false
+		}
+	}
+	return true
+}
+
+// Range executes the given function for every KeyVal pair in the Hamt. KeyVal
+// pairs are visited in a seeminly random order.
+func (h *HamtCompact) Range(fn func(KeyI, interface{}) bool) {
+	h.root.rangeNode(fn)
+}
+
+// RangeSorted executes the given function for every KeyVal pair in the Hamt in
+// the order defined by less; for example NaturalLess.
+func (h *HamtCompact) RangeSorted(
+	less func(a, b KeyI) bool,
+	fn func(KeyI, interface{}) bool,
+) {
+	var kvs = make([]KeyVal, 0, h.nentries)
+	h.Range(func(k KeyI, v interface{}) bool {
+		kvs = append(kvs, KeyVal{k, v})
+		return true
+	})
+	sort.Slice(kvs, func(i, j int) bool {
+		return less(kvs[i].Key, kvs[j].Key)
+	})
+	for _, kv := range kvs {
+		if !fn(kv.Key, kv.Val) {
+			break
+		}
+	}
+}
+
+// Stats walks the Hamt and populates a Stats data struture which it returns.
+// Every node, other than collision nodes, is counted as a SparseTable, every
+// inline KeyVal pair as a FlatLeaf, and every collision node as a
+// CollisionLeaf; there are no empty slots, so Nils is zero.
+func (h *HamtCompact) Stats() *Stats {
+	var stats = new(Stats)
+
+	var walk func(n *champNode, depth uint)
+	walk = func(n *champNode, depth uint) {
+		stats.Nodes++
+		if depth == DepthLimit {
+			stats.Leafs++
+			stats.CollisionLeafs++
+			stats.KeyVals += uint(len(n.kvs))
+			return
+		}
+
+		stats.Tables++
+		stats.SparseTables++
+		stats.TableCountsByNentries[bitCount32(n.dataMap|n.nodeMap)]++
+		stats.TableCountsByDepth[depth]++
+		if depth > stats.MaxDepth {
+			stats.MaxDepth = depth
+		}
+
+		stats.Nodes += uint(len(n.kvs))
+		stats.Leafs += uint(len(n.kvs))
+		stats.FlatLeafs += uint(len(n.kvs))
+		stats.KeyVals += uint(len(n.kvs))
+
+		for _, child := range n.nodes {
+			walk(child, depth+1)
+		}
+	}
+	walk(h.root, 0)
+
+	return stats
+}
+
+// Validate walks the entire HamtCompact and checks every structural invariant.
+// It returns nil if the HamtCompact is sound, otherwise an error describing
+// the first violation found.
+func (h *HamtCompact) Validate() error {
+	if h.root == nil {
+		return errors.New("Validate: nil root")
+	}
+	var nkvs uint
+	var err = h.root.validate(0, 0, &nkvs)
+	if err != nil {
+		return errors.Wrap(err, "Validate")
+	}
+	if nkvs != h.nentries {
+		return errors.Errorf("Validate: nentries,%d != number of KeyVal pairs,%d",
+			h.nentries, nkvs)
+	}
+	return nil
+}
+
+func (n *champNode) validate(hashPath HashVal, depth uint, nkvs *uint) error {
+	var where = hashPath.HashPathString(depth)
+
+	if depth == DepthLimit {
+		if n.dataMap != 0 || n.nodeMap != 0 || len(n.nodes) != 0 {
+			return errors.Errorf("collision node at %s has bitmaps or nodes",
+				where)
+		}
+		if len(n.kvs) < 2 {
+			return errors.Errorf("collision node at %s has %d KeyVal pairs",
+				where, len(n.kvs))
+		}
+		var mask = hashPathMask(DepthLimit)
+		for i, e := range n.kvs {
+			if e.hv&mask != n.kvs[0].hv&mask || e.hv != e.key.Hash() {
+				return errors.Errorf("collision node at %s: key %s has "+
+					"HashVal %s", where, e.key, e.hv)
+			}
+			for _, o := range n.kvs[:i] {
+				if o.key.Equals(e.key) {
+					return errors.Errorf("collision node at %s: duplicate "+
+						"key %s", where, e.key)
+				}
+			}
+		}
+		*nkvs += uint(len(n.kvs))
+		return nil
+	}
+
+	if n.dataMap&n.nodeMap != 0 {
+		return errors.Errorf("node at %s: dataMap and nodeMap overlap", where)
+	}
+	if int(bitCount32(n.dataMap)) != len(n.kvs) ||
+		int(bitCount32(n.nodeMap)) != len(n.nodes) {
+		return errors.Errorf("node at %s: bitmaps do not match %d kvs and "+
+			"%d nodes", where, len(n.kvs), len(n.nodes))
+	}
+	if depth > 0 && len(n.nodes) == 0 && len(n.kvs) < 2 {
+		return errors.Errorf("node at %s is not canonical; %d kvs, no nodes",
+			where, len(n.kvs))
+	}
+
+	for idx := uint(0); idx < IndexLimit; idx++ {
+		var bit = uint32(1) << idx
+		switch {
+		case n.dataMap&bit != 0:
+			var e = n.kvs[n.dataIndex(bit)]
+			if e.hv != e.key.Hash() {
+				return errors.Errorf("key %s at %s: stored HashVal %s != "+
+					"key.Hash() %s", e.key, where, e.hv, e.key.Hash())
+			}
+			if e.hv&hashPathMask(depth+1) != hashPath.buildHashPath(idx, depth) {
+				return errors.Errorf("key %s with HashVal %s stored at %s",
+					e.key, e.hv, hashPath.buildHashPath(idx, depth).
+						HashPathString(depth+1))
+			}
+			*nkvs++
+		case n.nodeMap&bit != 0:
+			var child = n.nodes[n.nodeIndex(bit)]
+			if child == nil {
+				return errors.Errorf("nil node at %s", hashPath.
+					buildHashPath(idx, depth).HashPathString(depth+1))
+			}
+			var err = child.validate(hashPath.buildHashPath(idx, depth),
+				depth+1, nkvs)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// walk calls fn with a flatLeaf for every KeyVal pair; HamtCompact has no
+// tables or leafs to visit. It returns false if fn stopped the traversal.
+func (h *HamtCompact) walk(fn visitFn) bool {
+	return h.root.walk(fn)
+}
+
+func (n *champNode) walk(fn visitFn) bool {
+	for _, e := range n.kvs {
+		if !fn(newFlatLeaf(e.hv, e.key, e.val)) {
+			return false
+		}
+	}
+	for _, child := range n.nodes {
+		if !child.walk(fn) {
+			return false
+		}
+	}
+	return true
+}
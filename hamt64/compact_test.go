@@ -0,0 +1,185 @@
+package hamt64_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/lleo/go-hamt/hamt64"
+)
+
+func TestHamt64Compact(t *testing.T) {
+	var name = "TestHamt64Compact"
+	var kvs = KVS64[:20000]
+
+	var h, err = buildHamt64(name, kvs, Functional, hamt64.CompactTables)
+	if err != nil {
+		t.Fatalf("%s: buildHamt64 failed: %s", name, err)
+	}
+	if _, ok := h.(*hamt64.HamtCompact); !ok {
+		t.Fatalf("%s: New(%t, CompactTables) returned a %T", name, Functional, h)
+	}
+	if h.Nentries() != uint(len(kvs)) {
+		t.Fatalf("%s: h.Nentries(),%d != %d", name, h.Nentries(), len(kvs))
+	}
+	err = h.Validate()
+	if err != nil {
+		t.Fatalf("%s: Validate() failed: %s", name, err)
+	}
+
+	for _, kv := range kvs {
+		var val, found = h.Get(kv.Key)
+		if !found || val != kv.Val {
+			t.Fatalf("%s: h.Get(%s) = %v, %t; expected %v, true",
+				name, kv.Key, val, found, kv.Val)
+		}
+	}
+
+	var n int
+	h.Range(func(k hamt64.KeyI, v interface{}) bool {
+		n++
+		return true
+	})
+	if n != len(kvs) {
+		t.Fatalf("%s: Range visited %d pairs; expected %d", name, n, len(kvs))
+	}
+
+	var stats = h.Stats()
+	if stats.KeyVals != uint(len(kvs)) || stats.FixedTables != 0 {
+		t.Fatalf("%s: Stats() = %+v", name, stats)
+	}
+
+	for i, kv := range kvs {
+		var added bool
+		h, added = h.Put(kv.Key, -i)
+		if added {
+			t.Fatalf("%s: h.Put(%s) of an existing key added it", name, kv.Key)
+		}
+	}
+	if h.Nentries() != uint(len(kvs)) {
+		t.Fatalf("%s: h.Nentries(),%d != %d after replacing every value",
+			name, h.Nentries(), len(kvs))
+	}
+
+	for i, kv := range kvs {
+		var val interface{}
+		var deleted bool
+		h, val, deleted = h.Del(kv.Key)
+		if !deleted || val != -i {
+			t.Fatalf("%s: h.Del(%s) = %v, %t; expected %d, true",
+				name, kv.Key, val, deleted, -i)
+		}
+		if i%1000 == 0 {
+			err = h.Validate()
+			if err != nil {
+				t.Fatalf("%s: Validate() failed after %d deletes: %s",
+					name, i+1, err)
+			}
+		}
+	}
+	if !h.IsEmpty() {
+		t.Fatalf("%s: h is not empty after deleting every key", name)
+	}
+}
+
+func TestHamt64CompactPersistence(t *testing.T) {
+	var name = "TestHamt64CompactPersistence"
+	var kvs = KVS64[:5000]
+
+	var h0, err = buildHamt64(name, kvs, true, hamt64.CompactTables)
+	if err != nil {
+		t.Fatalf("%s: buildHamt64 failed: %s", name, err)
+	}
+
+	var h1 = h0
+	for _, kv := range kvs[:2500] {
+		h1, _, _ = h1.Del(kv.Key)
+	}
+	for _, kv := range kvs[2500:3000] {
+		h1, _ = h1.Put(kv.Key, "new")
+	}
+
+	if h0.Nentries() != uint(len(kvs)) {
+		t.Fatalf("%s: h0.Nentries(),%d != %d", name, h0.Nentries(), len(kvs))
+	}
+	for _, kv := range kvs {
+		var val, found = h0.Get(kv.Key)
+		if !found || val != kv.Val {
+			t.Fatalf("%s: h0.Get(%s) = %v, %t; expected %v, true",
+				name, kv.Key, val, found, kv.Val)
+		}
+	}
+	for _, h := range []hamt64.Hamt{h0, h1} {
+		err = h.Validate()
+		if err != nil {
+			t.Fatalf("%s: Validate() failed: %s", name, err)
+		}
+	}
+
+	// A transient copy modifies in place, but not the nodes it was copied
+	// from once it has been deep copied.
+	var tr = h0.DeepCopy().ToTransient()
+	for _, kv := range kvs {
+		tr.Del(kv.Key)
+	}
+	if !tr.IsEmpty() || h0.Nentries() != uint(len(kvs)) {
+		t.Fatalf("%s: tr.Nentries(),%d h0.Nentries(),%d",
+			name, tr.Nentries(), h0.Nentries())
+	}
+	if _, found := h0.Get(kvs[0].Key); !found {
+		t.Fatalf("%s: deleting from a deep copy changed h0", name)
+	}
+}
+
+func TestHamt64CompactCollisions(t *testing.T) {
+	var name = "TestHamt64CompactCollisions"
+
+	var h hamt64.Hamt = hamt64.NewCompact(Functional)
+
+	// groups[i] keys share the HashVal i+1.
+	var groups = []int{1, 2, 3, 5}
+	var keys []fixedHashKey
+	for i, n := range groups {
+		for j := 0; j < n; j++ {
+			var k = fixedHashKey{fmt.Sprintf("k%d.%d", i, j), hamt64.HashVal(i + 1)}
+			h, _ = h.Put(k, k.s)
+			keys = append(keys, k)
+		}
+	}
+	for _, kv := range KVS64[:1000] {
+		h, _ = h.Put(kv.Key, kv.Val)
+	}
+
+	var err = h.Validate()
+	if err != nil {
+		t.Fatalf("%s: Validate() failed: %s", name, err)
+	}
+	if h.Stats().CollisionLeafs != 3 {
+		t.Fatalf("%s: Stats().CollisionLeafs,%d != 3",
+			name, h.Stats().CollisionLeafs)
+	}
+
+	for _, k := range keys {
+		var val, found = h.Get(k)
+		if !found || val != k.s {
+			t.Fatalf("%s: h.Get(%s) = %v, %t", name, k, val, found)
+		}
+	}
+
+	for _, k := range keys {
+		var deleted bool
+		h, _, deleted = h.Del(k)
+		if !deleted {
+			t.Fatalf("%s: h.Del(%s) failed", name, k)
+		}
+		err = h.Validate()
+		if err != nil {
+			t.Fatalf("%s: Validate() failed after h.Del(%s): %s", name, k, err)
+		}
+		if _, found := h.Get(k); found {
+			t.Fatalf("%s: h.Get(%s) found a deleted key", name, k)
+		}
+	}
+	if h.Nentries() != 1000 {
+		t.Fatalf("%s: h.Nentries(),%d != 1000", name, h.Nentries())
+	}
+}
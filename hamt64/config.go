@@ -0,0 +1,83 @@
+package hamt64
+
+import "fmt"
+
+// Config describes how a Hamt was constructed. It is returned by the Config
+// method of every Hamt.
+type Config struct {
+	// Functional is true for a functional (copy on write) Hamt and false for
+	// a transient (modify in place) one.
+	Functional bool
+
+	// TableOption is one of HybridTables, FixedTables, SparseTables, or
+	// CompactTables; TableOptionName holds its name.
+	TableOption     int
+	TableOptionName string
+
+	// HashSize is the width of HashVal in bits, and NumIndexBits and
+	// DepthLimit the package constants of the same names.
+	HashSize     uint
+	NumIndexBits uint
+	DepthLimit   uint
+
+	// Hasher names the hash function CalcHash, and the provided key types,
+	// use.
+	Hasher string
+}
+
+// newConfig returns the Config of a Hamt of this package.
+func newConfig(functional bool, tblOpt int) Config {
+	return Config{
+		Functional:      functional,
+		TableOption:     tblOpt,
+		TableOptionName: TableOptionName[tblOpt],
+		HashSize:        hashSize,
+		NumIndexBits:    NumIndexBits,
+		DepthLimit:      DepthLimit,
+		Hasher:          hasherName,
+	}
+}
+
+// New returns a new, empty Hamt with the mode and table option of c. A Hamt
+// that is to be filled with a copy of the entries of another is compatible
+// with it if constructed from its Config.
+func (c Config) New() Hamt {
+	return New(c.Functional, c.TableOption)
+}
+
+// String returns a one line description of c.
+func (c Config) String() string {
+	var mode = "transient"
+	if c.Functional {
+		mode = "functional"
+	}
+	return fmt.Sprintf("Config{ %s, %s, HashSize: %d, NumIndexBits: %d, "+
+		"DepthLimit: %d, Hasher: %s }", mode, c.TableOptionName, c.HashSize,
+		c.NumIndexBits, c.DepthLimit, c.Hasher)
+}
+
+// tableOption returns the table option h was constructed with.
+func (h *hamtBase) tableOption() int {
+	switch {
+	case h.startFixed:
+		return FixedTables
+	case h.nograde:
+		return SparseTables
+	}
+	return HybridTables
+}
+
+// Config returns the mode, table option, and hash configuration of h.
+func (h *HamtFunctional) Config() Config {
+	return newConfig(true, h.tableOption())
+}
+
+// Config returns the mode, table option, and hash configuration of h.
+func (h *HamtTransient) Config() Config {
+	return newConfig(false, h.tableOption())
+}
+
+// Config returns the mode, table option, and hash configuration of h.
+func (h *HamtCompact) Config() Config {
+	return newConfig(!h.transient, CompactTables)
+}
@@ -0,0 +1,78 @@
+package hamt64_test
+
+import (
+	"strings"
+	"testing"
+	"unsafe"
+
+	"github.com/lleo/go-hamt/hamt64"
+)
+
+func TestHamt64Config(t *testing.T) {
+	var name = "TestHamt64Config"
+
+	var opts = []int{hamt64.HybridTables, hamt64.FixedTables,
+		hamt64.SparseTables, hamt64.CompactTables}
+	for _, functional := range []bool{true, false} {
+		for _, opt := range opts {
+			var h = hamt64.New(functional, opt)
+			for _, kv := range KVS64[:100] {
+				h, _ = h.Put(kv.Key, kv.Val)
+			}
+
+			var c = h.Config()
+			if c.Functional != functional || c.TableOption != opt ||
+				c.TableOptionName != hamt64.TableOptionName[opt] {
+				t.Fatalf("%s: New(%t, %s).Config() = %s", name, functional,
+					hamt64.TableOptionName[opt], c)
+			}
+			if c.HashSize != uint(unsafe.Sizeof(hamt64.HashVal(0)))*8 ||
+				c.NumIndexBits != hamt64.NumIndexBits ||
+				c.DepthLimit != hamt64.DepthLimit || c.Hasher == "" {
+				t.Fatalf("%s: Config() = %s", name, c)
+			}
+
+			if !strings.Contains(h.String(), c.String()) {
+				t.Fatalf("%s: String() %q does not include the Config",
+					name, h.String())
+			}
+			if !strings.Contains(h.LongString(""), c.String()) {
+				t.Fatalf("%s: LongString() does not include the Config", name)
+			}
+
+			// The Config survives mode and layout changes.
+			if h.ToTransient().Config().Functional ||
+				!h.ToFunctional().Config().Functional {
+				t.Fatalf("%s: ToTransient/ToFunctional Config() is wrong",
+					name)
+			}
+			if h.Relayout(hamt64.SparseTables).Config().TableOption !=
+				hamt64.SparseTables {
+				t.Fatalf("%s: Relayout(SparseTables).Config() = %s",
+					name, h.Relayout(hamt64.SparseTables).Config())
+			}
+
+			var nh = c.New()
+			if !nh.IsEmpty() || nh.Config() != c {
+				t.Fatalf("%s: Config().New().Config() = %s; expected %s",
+					name, nh.Config(), c)
+			}
+		}
+	}
+}
+
+// TestHamt64ConfigCompactFallback checks the documented behavior of
+// NewFunctional and NewTransient given CompactTables: they build a
+// HybridTables Hamt, and say so.
+func TestHamt64ConfigCompactFallback(t *testing.T) {
+	var name = "TestHamt64ConfigCompactFallback"
+
+	var hs = []hamt64.Hamt{hamt64.NewFunctional(hamt64.CompactTables),
+		hamt64.NewTransient(hamt64.CompactTables)}
+	for _, h := range hs {
+		if opt := h.Config().TableOption; opt != hamt64.HybridTables {
+			t.Fatalf("%s: %T built with CompactTables has Config().TableOption "+
+				"%s; expected HybridTables", name, h, hamt64.TableOptionName[opt])
+		}
+	}
+}
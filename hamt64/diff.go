@@ -0,0 +1,150 @@
+package hamt64
+
+import (
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// Diff returns the changes that turn from into to, as Inserted, Replaced, and
+// Deleted Events in hash order.
+//
+// Diff walks both Hamts together and skips every table and leaf they share,
+// so diffing two versions of a HamtFunctional costs time proportional to
+// their differences rather than their size.
+//
+// A key whose value is == in both is unchanged. Values of types that are not
+// comparable are always reported as Replaced if their leafs differ.
+func Diff(from, to Hamt) ([]Event, error) {
+	var a, err = baseOf(from)
+	if err != nil {
+		return nil, errors.Wrap(err, "Diff: from")
+	}
+	var b *hamtBase
+	b, err = baseOf(to)
+	if err != nil {
+		return nil, errors.Wrap(err, "Diff: to")
+	}
+
+	var events []Event
+	diffNodes(&a.root, &b.root, func(e Event) {
+		events = append(events, e)
+	})
+
+	return events, nil
+}
+
+// diffNodes calls fn for every difference between the nodes a and b, which
+// occupy the same slot of two Hamts. Either may be nil.
+func diffNodes(a, b nodeI, fn func(Event)) {
+	if a == b {
+		return // shared, or both nil
+	}
+
+	var ta, aIsTable = a.(tableI)
+	var tb, bIsTable = b.(tableI)
+	if aIsTable && bIsTable {
+		for idx := uint(0); idx < IndexLimit; idx++ {
+			diffNodes(ta.get(idx), tb.get(idx), fn)
+		}
+		return
+	}
+
+	// At least one side is a leaf or nil, so it holds few KeyVals. The other
+	// side may be a whole subtree, all of whose KeyVals are collected and
+	// compared against those few.
+	var akvs, bkvs = collectKeyVals(a), collectKeyVals(b)
+
+AIter:
+	for _, akv := range akvs {
+		for _, bkv := range bkvs {
+			if akv.Key.Equals(bkv.Key) {
+				if !sameVal(akv.Val, bkv.Val) {
+					fn(Event{Kind: Replaced, Key: bkv.Key,
+						OldVal: akv.Val, NewVal: bkv.Val})
+				}
+				continue AIter
+			}
+		}
+		fn(Event{Kind: Deleted, Key: akv.Key, OldVal: akv.Val})
+	}
+
+BIter:
+	for _, bkv := range bkvs {
+		for _, akv := range akvs {
+			if bkv.Key.Equals(akv.Key) {
+				continue BIter
+			}
+		}
+		fn(Event{Kind: Inserted, Key: bkv.Key, NewVal: bkv.Val})
+	}
+}
+
+// keyChanged reports whether the value of key differs between a and b. It
+// follows the hash path of key down both Hamts together and returns false as
+// soon as they share a node, so for two versions of a HamtFunctional that
+// differ elsewhere it costs less than one Get.
+func keyChanged(a, b *hamtBase, key KeyI) bool {
+	var hv HashVal
+	key, hv = hashKey(key)
+
+	var na, nb nodeI = &a.root, &b.root
+	for depth := uint(0); depth <= maxDepth; depth++ {
+		if na == nb {
+			return false
+		}
+		var ta, aIsTable = na.(tableI)
+		var tb, bIsTable = nb.(tableI)
+		if !aIsTable || !bIsTable {
+			break
+		}
+		var idx = hv.Index(depth)
+		na, nb = ta.get(idx), tb.get(idx)
+	}
+	if na == nb {
+		return false
+	}
+
+	var va, fa = a.Get(key)
+	var vb, fb = b.Get(key)
+	return fa != fb || !sameVal(va, vb)
+}
+
+// collectKeyVals returns the KeyVal pairs stored in and below n.
+func collectKeyVals(n nodeI) []KeyVal {
+	if n == nil {
+		return nil
+	}
+	var kvs []KeyVal
+	n.visit(func(n nodeI) bool {
+		if l, isLeaf := n.(leafI); isLeaf {
+			kvs = append(kvs, l.keyVals()...)
+		}
+		return true
+	})
+	return kvs
+}
+
+// sameVal reports whether a and b are ==, without panicking for values of
+// types that are not comparable. Values that can not be compared are never
+// the same, so a Replaced Event is reported for them.
+func sameVal(a, b interface{}) (same bool) {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	var t = reflect.TypeOf(a)
+	if t != reflect.TypeOf(b) || !t.Comparable() {
+		return false
+	}
+	switch t.Kind() {
+	case reflect.Struct, reflect.Array:
+		// A comparable struct or array type may still hold an interface
+		// value of a type that is not, for which == panics.
+		defer func() {
+			if recover() != nil {
+				same = false
+			}
+		}()
+	}
+	return a == b
+}
@@ -0,0 +1,118 @@
+package hamt64_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/lleo/go-hamt/hamt64"
+)
+
+func TestHamt64Diff(t *testing.T) {
+	var name = "TestHamt64Diff"
+	var kvs = KVS64[:5000]
+
+	var from, err = buildHamt64(name, kvs, Functional, TableOption)
+	if err != nil {
+		t.Fatalf("%s: buildHamt64 failed: %s", name, err)
+	}
+
+	var to = from.DeepCopy()
+	if Functional {
+		to = from
+	}
+	var expected = make(map[string]hamt64.EventKind)
+	for _, kv := range kvs[:100] {
+		to, _, _ = to.Del(kv.Key)
+		expected[fmt.Sprint(kv.Key)] = hamt64.Deleted
+	}
+	for _, kv := range kvs[100:200] {
+		to, _ = to.Put(kv.Key, -1)
+		expected[fmt.Sprint(kv.Key)] = hamt64.Replaced
+	}
+	for _, kv := range kvs[200:300] {
+		to, _ = to.Put(kv.Key, kv.Val) // unchanged
+	}
+	for i := 0; i < 100; i++ {
+		var k = hamt64.StringKey(fmt.Sprint("new key ", i))
+		to, _ = to.Put(k, i)
+		expected[fmt.Sprint(k)] = hamt64.Inserted
+	}
+
+	var events []hamt64.Event
+	events, err = hamt64.Diff(from, to)
+	if err != nil {
+		t.Fatalf("%s: Diff failed: %s", name, err)
+	}
+	if len(events) != len(expected) {
+		t.Fatalf("%s: Diff returned %d Events; expected %d",
+			name, len(events), len(expected))
+	}
+	for _, e := range events {
+		var s = fmt.Sprint(e.Key)
+		if expected[s] != e.Kind {
+			t.Fatalf("%s: Diff reported %s %s; expected %s",
+				name, e.Kind, s, expected[s])
+		}
+		var oldVal, _ = from.Get(e.Key)
+		var newVal, _ = to.Get(e.Key)
+		if e.OldVal != oldVal || e.NewVal != newVal {
+			t.Fatalf("%s: %s %s: OldVal,NewVal = %v,%v; expected %v,%v",
+				name, e.Kind, s, e.OldVal, e.NewVal, oldVal, newVal)
+		}
+	}
+
+	// Replaying the Diff onto from gives to.
+	var replay = from.DeepCopy()
+	for _, e := range events {
+		if e.Kind == hamt64.Deleted {
+			replay, _, _ = replay.Del(e.Key)
+		} else {
+			replay, _ = replay.Put(e.Key, e.NewVal)
+		}
+	}
+	events, _ = hamt64.Diff(replay, to)
+	if len(events) != 0 {
+		t.Fatalf("%s: replayed Diff differs from to: %v", name, events)
+	}
+
+	events, _ = hamt64.Diff(to, to)
+	if len(events) != 0 {
+		t.Fatalf("%s: Diff(to, to) returned %d Events", name, len(events))
+	}
+
+	// Values that are not comparable do not panic.
+	var a = hamt64.New(Functional, TableOption)
+	a, _ = a.Put(hamt64.StringKey("slice"), []int{1})
+	var b, _ = a.DeepCopy().Put(hamt64.StringKey("slice"), []int{1})
+	events, _ = hamt64.Diff(a, b)
+	if len(events) != 1 || events[0].Kind != hamt64.Replaced {
+		t.Fatalf("%s: Diff of slice values = %v", name, events)
+	}
+}
+
+// TestHamt64DiffIncomparableVal checks that Diff reports a Replaced Event,
+// rather than panicking, for values of a comparable struct type holding an
+// interface value of a type that is not comparable.
+func TestHamt64DiffIncomparableVal(t *testing.T) {
+	var name = "TestHamt64DiffIncomparableVal"
+
+	type holder struct{ v interface{} }
+
+	var from = hamt64.New(Functional, TableOption)
+	from, _ = from.Put(hamt64.StringKey("k"), holder{[]int{1}})
+
+	var to = from.DeepCopy()
+	if Functional {
+		to = from
+	}
+	to, _ = to.Put(hamt64.StringKey("k"), holder{[]int{2}})
+
+	var events, err = hamt64.Diff(from, to)
+	if err != nil {
+		t.Fatalf("%s: Diff failed: %s", name, err)
+	}
+	if len(events) != 1 || events[0].Kind != hamt64.Replaced {
+		t.Fatalf("%s: Diff returned %v; expected one Replaced Event",
+			name, events)
+	}
+}
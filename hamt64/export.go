@@ -0,0 +1,329 @@
+package hamt64
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ExportOptions controls how much of a Hamt is rendered by WriteDot, Dump and
+// WriteJSON. A nil *ExportOptions is the same as the zero value, which
+// renders everything.
+type ExportOptions struct {
+	// MaxDepth is the depth of the deepest table rendered; the contents of
+	// deeper tables are elided. Zero means no limit.
+	MaxDepth uint
+
+	// MaxNodes is the maximum number of nodes rendered; once reached the
+	// remaining nodes are elided. Zero means no limit.
+	MaxNodes uint
+
+	// KeyVals indicates the KeyVal pairs of leafs should be rendered as well.
+	KeyVals bool
+
+	// Compare is another version of the Hamt, usually an older
+	// HamtFunctional. When it is set, WriteDot draws both versions in the
+	// same graph and every node reachable from both is drawn once and
+	// highlighted as shared; Dump marks those nodes with Shared=true.
+	Compare Hamt
+}
+
+// NodeDump is the JSON friendly description of a single node of the Hamt
+// trie, as returned by Dump.
+type NodeDump struct {
+	// Type is one of "fixedTable", "sparseTable", "flatLeaf", or
+	// "collisionLeaf".
+	Type string `json:"type"`
+
+	// Index is the slot this node occupies in its parent table.
+	Index uint `json:"index"`
+
+	// Depth is the depth of a table, or of the table holding a leaf.
+	Depth uint `json:"depth"`
+
+	// HashPath is the hashPath of a table or the full hash of a leaf, in
+	// HashPathString form.
+	HashPath string `json:"hashPath"`
+
+	// Bitmap is the bitmap of occupied slots of a table.
+	Bitmap string `json:"bitmap,omitempty"`
+
+	// Nentries is the number of entries of a table or KeyVals of a leaf.
+	Nentries uint `json:"nentries"`
+
+	// KeyVals holds the string form of a leaf's KeyVal pairs, if requested.
+	KeyVals []string `json:"keyVals,omitempty"`
+
+	// Shared indicates this node is also part of the ExportOptions.Compare
+	// Hamt.
+	Shared bool `json:"shared,omitempty"`
+
+	// Elided is the number of child nodes not rendered due to the
+	// ExportOptions limits.
+	Elided uint `json:"elided,omitempty"`
+
+	Children []*NodeDump `json:"children,omitempty"`
+}
+
+// baseOf returns the hamtBase underlying a Hamt.
+func baseOf(h Hamt) (*hamtBase, error) {
+	switch x := h.(type) {
+	case *HamtFunctional:
+		return &x.hamtBase, nil
+	case *HamtTransient:
+		return &x.hamtBase, nil
+	}
+	return nil, errors.Errorf("unsupported Hamt implementation %T", h)
+}
+
+// tableBitmap builds a bitmap of the occupied slots of any tableI.
+func tableBitmap(t tableI) bitmap {
+	if st, ok := t.(*sparseTable); ok {
+		return st.nodeMap
+	}
+	var bm bitmap
+	for _, ent := range t.entries() {
+		bm.Set(ent.idx)
+	}
+	return bm
+}
+
+// nodeName returns the name of a node type as used by Dump and WriteDot.
+func nodeName(n nodeI) string {
+	switch n.(type) {
+	case *fixedTable:
+		return "fixedTable"
+	case *sparseTable:
+		return "sparseTable"
+	case *flatLeaf:
+		return "flatLeaf"
+	case *collisionLeaf:
+		return "collisionLeaf"
+	}
+	return fmt.Sprintf("%T", n)
+}
+
+// exporter holds the state shared by Dump and WriteDot while they traverse a
+// Hamt.
+type exporter struct {
+	opts   ExportOptions
+	shared map[nodeI]bool
+	nnodes uint
+}
+
+func newExporter(opts *ExportOptions) (*exporter, error) {
+	var e = new(exporter)
+	if opts != nil {
+		e.opts = *opts
+	}
+
+	if e.opts.Compare != nil {
+		var cmp, err = baseOf(e.opts.Compare)
+		if err != nil {
+			return nil, err
+		}
+		e.shared = make(map[nodeI]bool)
+		cmp.walk(func(n nodeI) bool {
+			if n != nil {
+				e.shared[n] = true
+			}
+			return true
+		})
+	}
+
+	return e, nil
+}
+
+// full returns true if the MaxNodes limit has been reached.
+func (e *exporter) full() bool {
+	return e.opts.MaxNodes > 0 && e.nnodes >= e.opts.MaxNodes
+}
+
+// descend returns true if the children of a table at depth should be rendered.
+func (e *exporter) descend(depth uint) bool {
+	return e.opts.MaxDepth == 0 || depth < e.opts.MaxDepth
+}
+
+func (e *exporter) leafKeyVals(l leafI) []string {
+	if !e.opts.KeyVals {
+		return nil
+	}
+	var kvs = l.keyVals()
+	var strs = make([]string, len(kvs))
+	for i, kv := range kvs {
+		strs[i] = kv.String()
+	}
+	return strs
+}
+
+// Dump returns a description of the Hamt trie rooted at the root table. It
+// is meant to be marshalled to JSON; see WriteJSON.
+func Dump(h Hamt, opts *ExportOptions) (*NodeDump, error) {
+	var hb, err = baseOf(h)
+	if err != nil {
+		return nil, errors.Wrap(err, "Dump")
+	}
+
+	var e *exporter
+	e, err = newExporter(opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "Dump")
+	}
+
+	return e.dumpNode(&hb.root, 0, 0), nil
+}
+
+func (e *exporter) dumpNode(n nodeI, idx, depth uint) *NodeDump {
+	e.nnodes++
+
+	var d = &NodeDump{
+		Type:   nodeName(n),
+		Index:  idx,
+		Depth:  depth,
+		Shared: e.shared[n],
+	}
+
+	switch x := n.(type) {
+	case tableI:
+		var bm = tableBitmap(x)
+		d.HashPath = x.Hash().HashPathString(depth)
+		d.Bitmap = bm.String()
+		d.Nentries = x.nentries()
+
+		for _, ent := range x.entries() {
+			if !e.descend(depth) || e.full() {
+				d.Elided++
+				continue
+			}
+			var childDepth = depth + 1
+			if _, isLeaf := ent.node.(leafI); isLeaf {
+				childDepth = depth
+			}
+			d.Children = append(d.Children,
+				e.dumpNode(ent.node, ent.idx, childDepth))
+		}
+	case leafI:
+		d.HashPath = x.Hash().String()
+		d.Nentries = uint(len(x.keyVals()))
+		d.KeyVals = e.leafKeyVals(x)
+	}
+
+	return d
+}
+
+// WriteJSON writes the Dump of the Hamt to w as indented JSON.
+func WriteJSON(w io.Writer, h Hamt, opts *ExportOptions) error {
+	var d, err = Dump(h, opts)
+	if err != nil {
+		return errors.Wrap(err, "WriteJSON")
+	}
+
+	var enc = json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return errors.Wrap(enc.Encode(d), "WriteJSON")
+}
+
+// dotEscape escapes the characters that are special inside a Graphviz record
+// label.
+var dotEscape = strings.NewReplacer(
+	`\`, `\\`, `"`, `\"`, `{`, `\{`, `}`, `\}`,
+	`|`, `\|`, `<`, `\<`, `>`, `\>`, "\n", `\n`,
+)
+
+// WriteDot writes a Graphviz DOT digraph of the Hamt trie to w. Tables are
+// drawn as records showing their depth, hashPath, entry count and bitmap;
+// leafs show their hash and, if requested, their KeyVal pairs.
+//
+// If opts.Compare is set, both Hamts are drawn in the same graph. Since a
+// node's identity is its address, every node the two versions share is drawn
+// once, filled, with edges from both parents; this is the structural sharing
+// of HamtFunctional made visible.
+func WriteDot(w io.Writer, h Hamt, opts *ExportOptions) error {
+	var hb, err = baseOf(h)
+	if err != nil {
+		return errors.Wrap(err, "WriteDot")
+	}
+
+	var e *exporter
+	e, err = newExporter(opts)
+	if err != nil {
+		return errors.Wrap(err, "WriteDot")
+	}
+
+	var bw = bufio.NewWriter(w)
+	var drawn = make(map[nodeI]bool)
+
+	fmt.Fprintln(bw, "digraph hamt {")
+	fmt.Fprintln(bw, "\tnode [shape=record, fontname=\"monospace\"];")
+
+	fmt.Fprintln(bw, "\tversion [shape=plaintext, label=\"Hamt\"];")
+	fmt.Fprintf(bw, "\tversion -> %s;\n", dotID(&hb.root))
+	e.dotNode(bw, drawn, &hb.root, 0)
+
+	if e.opts.Compare != nil {
+		var cmp, _ = baseOf(e.opts.Compare)
+		fmt.Fprintln(bw, "\tcompare [shape=plaintext, label=\"Compare\"];")
+		fmt.Fprintf(bw, "\tcompare -> %s [style=dashed];\n", dotID(&cmp.root))
+		e.dotNode(bw, drawn, &cmp.root, 0)
+	}
+
+	fmt.Fprintln(bw, "}")
+
+	return errors.Wrap(bw.Flush(), "WriteDot")
+}
+
+func dotID(n nodeI) string {
+	return fmt.Sprintf("\"%p\"", n)
+}
+
+func (e *exporter) dotNode(w io.Writer, drawn map[nodeI]bool, n nodeI, depth uint) {
+	if drawn[n] {
+		return
+	}
+	drawn[n] = true
+	e.nnodes++
+
+	var style string
+	if e.shared[n] {
+		style = ", style=filled, fillcolor=lightgrey"
+	}
+
+	switch x := n.(type) {
+	case tableI:
+		var bm = tableBitmap(x)
+		fmt.Fprintf(w, "\t%s [label=\"{%s|depth=%d hashPath=%s|nentries=%d|%s}\"%s];\n",
+			dotID(n), nodeName(n), depth,
+			dotEscape.Replace(x.Hash().HashPathString(depth)),
+			x.nentries(), bm.String(), style)
+
+		var elided uint
+		for _, ent := range x.entries() {
+			if !e.descend(depth) || e.full() {
+				elided++
+				continue
+			}
+			fmt.Fprintf(w, "\t%s -> %s [label=\"%d\"];\n",
+				dotID(n), dotID(ent.node), ent.idx)
+			e.dotNode(w, drawn, ent.node, depth+1)
+		}
+		if elided > 0 {
+			fmt.Fprintf(w, "\t\"%p...\" [shape=plaintext, label=\"%d elided\"];\n",
+				n, elided)
+			fmt.Fprintf(w, "\t%s -> \"%p...\" [style=dotted];\n", dotID(n), n)
+		}
+	case leafI:
+		var fields = []string{
+			nodeName(n),
+			dotEscape.Replace(x.Hash().String()),
+		}
+		for _, kv := range e.leafKeyVals(x) {
+			fields = append(fields, dotEscape.Replace(kv))
+		}
+		fmt.Fprintf(w, "\t%s [shape=Mrecord, label=\"{%s}\"%s];\n",
+			dotID(n), strings.Join(fields, "|"), style)
+	}
+}
@@ -0,0 +1,93 @@
+package hamt64_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/lleo/go-hamt/hamt64"
+)
+
+// countDumpKeyVals sums the Nentries of every leaf in a NodeDump tree.
+func countDumpKeyVals(d *hamt64.NodeDump) uint {
+	if d.Type == "flatLeaf" || d.Type == "collisionLeaf" {
+		return d.Nentries
+	}
+	var n uint
+	for _, c := range d.Children {
+		n += countDumpKeyVals(c)
+	}
+	return n
+}
+
+func TestHamt64WriteJSON(t *testing.T) {
+	var h, err = buildHamt64("TestHamt64WriteJSON", KVS64[:1000],
+		Functional, TableOption)
+	if err != nil {
+		t.Fatalf("TestHamt64WriteJSON: buildHamt64 failed: %s", err)
+	}
+
+	var buf bytes.Buffer
+	err = hamt64.WriteJSON(&buf, h, nil)
+	if err != nil {
+		t.Fatalf("TestHamt64WriteJSON: WriteJSON failed: %s", err)
+	}
+
+	var d hamt64.NodeDump
+	err = json.Unmarshal(buf.Bytes(), &d)
+	if err != nil {
+		t.Fatalf("TestHamt64WriteJSON: json.Unmarshal failed: %s", err)
+	}
+
+	if d.Type != "fixedTable" || d.Depth != 0 {
+		t.Fatalf("TestHamt64WriteJSON: root dumped as %s at depth %d",
+			d.Type, d.Depth)
+	}
+
+	var n = countDumpKeyVals(&d)
+	if n != h.Nentries() {
+		t.Fatalf("TestHamt64WriteJSON: dump has %d KeyVals; h.Nentries()=%d",
+			n, h.Nentries())
+	}
+
+	var limited *hamt64.NodeDump
+	limited, err = hamt64.Dump(h, &hamt64.ExportOptions{MaxDepth: 1})
+	if err != nil {
+		t.Fatalf("TestHamt64WriteJSON: Dump failed: %s", err)
+	}
+	for _, c := range limited.Children {
+		if len(c.Children) != 0 {
+			t.Fatalf("TestHamt64WriteJSON: MaxDepth=1 dumped depth 2 nodes")
+		}
+	}
+}
+
+func TestHamt64WriteDot(t *testing.T) {
+	var old = hamt64.NewFunctional(TableOption)
+	var h hamt64.Hamt = old
+	for _, kv := range KVS64[:200] {
+		h, _ = h.Put(kv.Key, kv.Val)
+	}
+	old = h.(*hamt64.HamtFunctional)
+
+	var nh, _ = old.Put(hamt64.StringKey("not in KVS64"), 0)
+
+	var buf bytes.Buffer
+	var err = hamt64.WriteDot(&buf, nh,
+		&hamt64.ExportOptions{Compare: old, KeyVals: true})
+	if err != nil {
+		t.Fatalf("TestHamt64WriteDot: WriteDot failed: %s", err)
+	}
+
+	var dot = buf.String()
+	if !strings.HasPrefix(dot, "digraph hamt {") {
+		t.Fatalf("TestHamt64WriteDot: output not a digraph: %q", dot[:40])
+	}
+	if !strings.Contains(dot, "fillcolor=lightgrey") {
+		t.Fatal("TestHamt64WriteDot: no shared nodes highlighted")
+	}
+	if !strings.Contains(dot, "not in KVS64") {
+		t.Fatal("TestHamt64WriteDot: new key not rendered")
+	}
+}
@@ -76,7 +76,8 @@ func createFixedTable(depth uint, leaf1 leafI, leaf2 *flatLeaf) tableI {
 	} else { //idx1 == idx2
 		var node nodeI
 		if depth == maxDepth {
-			node = newCollisionLeaf(append(leaf1.keyVals(), leaf2.keyVals()...))
+			node = newCollisionLeaf(leaf1.Hash(),
+				append(leaf1.keyVals(), leaf2.keyVals()...))
 		} else {
 			node = createFixedTable(depth+1, leaf1, leaf2)
 		}
@@ -4,20 +4,24 @@ import (
 	"fmt"
 )
 
+// flatLeaf stores the HashVal of its key, hv, so the key is only hashed once;
+// when the key is first inserted.
 type flatLeaf struct {
+	hv  HashVal
 	key KeyI
 	val interface{}
 }
 
-func newFlatLeaf(key KeyI, val interface{}) *flatLeaf {
+func newFlatLeaf(hv HashVal, key KeyI, val interface{}) *flatLeaf {
 	var fl = new(flatLeaf)
+	fl.hv = hv
 	fl.key = key
 	fl.val = val
 	return fl
 }
 
 func (l *flatLeaf) Hash() HashVal {
-	return l.key.Hash()
+	return l.hv
 }
 
 func (l *flatLeaf) String() string {
@@ -41,11 +45,11 @@ func (l *flatLeaf) put(key KeyI, val interface{}) (leafI, bool) {
 
 	if l.key.Equals(key) {
 		// maintain functional behavior of flatLeaf
-		nl = newFlatLeaf(l.key, val)
+		nl = newFlatLeaf(l.hv, l.key, val)
 		return nl, false //replaced
 	}
 
-	nl = newCollisionLeaf([]KeyVal{{l.key, l.val}, {key, val}})
+	nl = newCollisionLeaf(l.hv, []KeyVal{{l.key, l.val}, {key, val}})
 	return nl, true // key,val was added
 }
 
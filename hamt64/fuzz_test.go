@@ -0,0 +1,161 @@
+// +build go1.18
+
+package hamt64_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/lleo/go-hamt/hamt64"
+)
+
+// The operations of a fuzz input. Every operation is two bytes; the op and the
+// key.
+const (
+	fuzzPut = iota
+	fuzzDel
+	fuzzGet
+	fuzzToTransient
+	fuzzToFunctional
+	fuzzDeepCopy
+	fuzzNumOps
+)
+
+// fuzzNumKeys is the size of the key space. It is small so that the same keys
+// are put and deleted over and over.
+const fuzzNumKeys = 32
+
+// fuzzMaxOps bounds the operations decoded from one input.
+const fuzzMaxOps = 512
+
+// fuzzKey returns key n under the hashing of mode. Mode 0 uses the real hash
+// of a StringKey. The other modes force collisions by giving the keys only 4
+// distinct HashVals, which differ at depth 0 in mode 1 and only at maxDepth in
+// mode 2, so the tables run all the way down to maxDepth.
+func fuzzKey(mode, n byte) hamt64.KeyI {
+	var s = fmt.Sprintf("k%02d", n)
+	switch mode {
+	case 1:
+		return fixedHashKey{s, hamt64.HashVal(n % 4)}
+	case 2:
+		return fixedHashKey{s, hamt64.HashVal(n%4) <<
+			((hamt64.DepthLimit - 1) * hamt64.NumIndexBits)}
+	}
+	return hamt64.StringKey(s)
+}
+
+// FuzzHamt64 applies the operations decoded from the input to a Hamt of every
+// table option and mode, and checks every result against a builtin map. It
+// also keeps every functional version created and checks, at the end, that no
+// later operation changed it.
+//
+// The seeds run as part of go test. To fuzz, select a single table option and
+// mode so TestMain runs the fuzz target only once:
+//
+//	go test -run XXX -fuzz FuzzHamt64 -args -H -f
+func FuzzHamt64(f *testing.F) {
+	f.Add([]byte{0, fuzzPut, 1, fuzzPut, 2, fuzzDel, 1, fuzzGet, 2})
+	f.Add([]byte{1, fuzzPut, 0, fuzzPut, 4, fuzzPut, 8, fuzzDel, 4,
+		fuzzDel, 0, fuzzDel, 8, fuzzGet, 4})
+	f.Add([]byte{2, fuzzPut, 1, fuzzPut, 5, fuzzPut, 2, fuzzToTransient, 0,
+		fuzzPut, 9, fuzzDel, 1, fuzzToFunctional, 0, fuzzDel, 5, fuzzDeepCopy,
+		0, fuzzDel, 9})
+
+	var seed []byte
+	for i := 0; i < 3*fuzzNumKeys; i++ {
+		seed = append(seed, fuzzPut, byte(i*7))
+	}
+	for i := 0; i < 3*fuzzNumKeys; i++ {
+		seed = append(seed, fuzzDel, byte(i*5))
+	}
+	for mode := byte(0); mode < 3; mode++ {
+		f.Add(append([]byte{mode}, seed...))
+	}
+
+	var opts = []int{hamt64.HybridTables, hamt64.FixedTables,
+		hamt64.SparseTables, hamt64.CompactTables}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if len(data) == 0 {
+			return
+		}
+		var mode = data[0] % 3
+		var ops = data[1:]
+		if len(ops) > 2*fuzzMaxOps {
+			ops = ops[:2*fuzzMaxOps]
+		}
+
+		for _, opt := range opts {
+			for _, functional := range []bool{true, false} {
+				fuzzHamt64(t, mode, ops, hamt64.New(functional, opt))
+			}
+		}
+	})
+}
+
+func fuzzHamt64(t *testing.T, mode byte, ops []byte, h hamt64.Hamt) {
+	var name = fmt.Sprintf("FuzzHamt64(%s)", h.Config())
+
+	var m = make(map[int]int)
+	var versions []testVersion
+	var key = func(n int) hamt64.KeyI { return fuzzKey(mode, byte(n)) }
+
+	for i := 0; i+1 < len(ops); i += 2 {
+		var step = i / 2
+		var n = int(ops[i+1] % fuzzNumKeys)
+		var k = key(n)
+
+		switch ops[i] % fuzzNumOps {
+		case fuzzPut:
+			var _, exists = m[n]
+			var added bool
+			h, added = h.Put(k, step)
+			if added == exists {
+				t.Fatalf("%s: step %d: Put(%s) added=%t; expected %t",
+					name, step, k, added, !exists)
+			}
+			m[n] = step
+		case fuzzDel:
+			var expected, exists = m[n]
+			var val interface{}
+			var deleted bool
+			h, val, deleted = h.Del(k)
+			if deleted != exists || (exists && val != expected) {
+				t.Fatalf("%s: step %d: Del(%s) = %v, %t; expected %v, %t",
+					name, step, k, val, deleted, expected, exists)
+			}
+			delete(m, n)
+		case fuzzGet:
+			var expected, exists = m[n]
+			var val, found = h.Get(k)
+			if found != exists || (exists && val != expected) {
+				t.Fatalf("%s: step %d: Get(%s) = %v, %t; expected %v, %t",
+					name, step, k, val, found, expected, exists)
+			}
+		case fuzzToTransient:
+			// The transient shares its tables with every functional
+			// version, which it may now modify; they can't be checked.
+			h = h.ToTransient()
+			versions = nil
+		case fuzzToFunctional:
+			h = h.ToFunctional()
+		case fuzzDeepCopy:
+			h = h.DeepCopy()
+		}
+
+		if h.Nentries() != uint(len(m)) {
+			t.Fatalf("%s: step %d: Nentries(),%d != %d",
+				name, step, h.Nentries(), len(m))
+		}
+		if h.Config().Functional {
+			versions = append(versions, testVersion{h, copyVersionMap(m),
+				fmt.Sprintf("version of step %d", step)})
+		}
+	}
+
+	checkVersion(t, name, testVersion{h, m, "final version"}, key,
+		fuzzNumKeys)
+	for _, v := range versions {
+		checkVersion(t, name, v, key, fuzzNumKeys)
+	}
+}
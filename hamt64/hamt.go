@@ -58,14 +58,19 @@ const (
 	// This was intended just save space, but also seems to be faster; CPU cache
 	// locality maybe?
 	SparseTables
+	// CompactTables indicates the structure should use the CHAMP node layout
+	// of HamtCompact, which stores KeyVal pairs inline rather than in leafs.
+	// Only New and NewCompact construct it; NewFunctional and NewTransient
+	// treat it as HybridTables.
+	CompactTables
 )
 
 // TableOptionName is a lookup table to map the integer value of
-// FixedTables, SparseTables, and HybridTables to a string representing
-// that option.
+// FixedTables, SparseTables, HybridTables, and CompactTables to a string
+// representing that option.
 //     var option = hamt64.FixedTables
 //     hamt64.TableOptionName[option] == "FixedTables"
-var TableOptionName [3]string
+var TableOptionName [4]string
 
 // Could have used...
 //var TableOptionName = [3]string{
@@ -78,6 +83,7 @@ func init() {
 	TableOptionName[FixedTables] = "FixedTables"
 	TableOptionName[SparseTables] = "SparseTables"
 	TableOptionName[HybridTables] = "HybridTables"
+	TableOptionName[CompactTables] = "CompactTables"
 }
 
 // Hamt defines the interface that both the HamtFunctional and HamtTransient
@@ -94,7 +100,12 @@ type Hamt interface {
 	String() string
 	LongString(string) string
 	Range(func(KeyI, interface{}) bool)
+	RangeSorted(func(a, b KeyI) bool, func(KeyI, interface{}) bool)
 	Stats() *Stats
+	Validate() error
+	Relayout(int) Hamt
+	Compact() Hamt
+	Config() Config
 	walk(visitFn) bool
 }
 
@@ -102,8 +113,9 @@ type Hamt interface {
 // as a key in this HAMT implementation.
 //
 // For provided types that popular data structures used as keys in other Map
-// implementations see the ByteSliceKey, StringKey, Int{32,64}Key, and
-// Uint{32,64}Key types provided by this library.
+// implementations see the ByteSliceKey, StringKey, Int{32,64}Key,
+// Uint{32,64}Key, Float64Key, BoolKey, RuneKey, and UintptrKey types provided
+// by this library.
 //
 // For instace, you can map "foo"->"bar" with a call to
 // h.Put(hamt64.StringKey("foo"), "bar") .
@@ -120,9 +132,13 @@ type KeyI interface {
 // HamtTransient data structure.
 //
 // The tblOpt argument is the table option defined by the constants
-// HybridTables, SparseTables, xor FixedTables.
+// HybridTables, SparseTables, FixedTables, xor CompactTables. With
+// CompactTables it implements a HamtCompact data structure.
 //
 func New(functional bool, tblOpt int) Hamt {
+	if tblOpt == CompactTables {
+		return NewCompact(functional)
+	}
 	if functional {
 		return NewFunctional(tblOpt)
 	}
@@ -170,4 +186,7 @@ type Stats struct {
 
 	// KeyVals is the total number of KeyVal pairs int the HAMT.
 	KeyVals uint
+
+	// Collisions describes the collisionLeafs in the HAMT.
+	Collisions CollisionReport
 }
@@ -2,6 +2,7 @@ package hamt64_test
 
 import (
 	"log"
+	"math/rand"
 	"testing"
 	"time"
 
@@ -29,10 +30,84 @@ func TestBuild64(t *testing.T) {
 			t.Fatalf("%s: failed to insert s=%q, v=%d", name, k, v)
 		}
 
+		var err = h.Validate()
+		if err != nil {
+			log.Printf("%s: Validate() failed after inserting s=%q: %s", name, k, err)
+			t.Fatalf("%s: Validate() failed after inserting s=%q: %s", name, k, err)
+		}
+
 		//log.Print(h.LongString(""))
 	}
 }
 
+func TestHamt64Validate(t *testing.T) {
+	runTestHamt64Validate(t, KVS64[:ValidateNumKvs], Functional, TableOption)
+}
+
+// runTestHamt64Validate Puts every KeyVal of kvs into a new Hamt, then Dels
+// them all in a random order, calling Validate() after every mutation.
+func runTestHamt64Validate(
+	t *testing.T,
+	kvs []hamt64.KeyVal,
+	functional bool,
+	tblOpt int,
+) {
+	var name = "TestHamt64Validate"
+	if functional {
+		name += ":functional:" + hamt64.TableOptionName[tblOpt]
+	} else {
+		name += ":transient:" + hamt64.TableOptionName[tblOpt]
+	}
+
+	StartTime[name] = time.Now()
+	var h = hamt64.New(functional, tblOpt)
+	for _, kv := range kvs {
+		var inserted bool
+		h, inserted = h.Put(kv.Key, kv.Val)
+		if !inserted {
+			t.Fatalf("%s: failed to h.Put(%q, %v)", name, kv.Key, kv.Val)
+		}
+
+		var err = h.Validate()
+		if err != nil {
+			log.Printf("%s: Validate() failed after h.Put(%q, %v): %s",
+				name, kv.Key, kv.Val, err)
+			t.Fatalf("%s: Validate() failed after h.Put(%q, %v): %s",
+				name, kv.Key, kv.Val, err)
+		}
+	}
+
+	var rnd = rand.New(rand.NewSource(int64(len(kvs))))
+	for _, i := range rnd.Perm(len(kvs)) {
+		var kv = kvs[i]
+
+		var val interface{}
+		var deleted bool
+		h, val, deleted = h.Del(kv.Key)
+		if !deleted {
+			t.Fatalf("%s: failed to h.Del(%q)", name, kv.Key)
+		}
+		if val != kv.Val {
+			t.Fatalf("%s: h.Del(%q) returned val,%v != expected,%v",
+				name, kv.Key, val, kv.Val)
+		}
+
+		var err = h.Validate()
+		if err != nil {
+			log.Printf("%s: Validate() failed after h.Del(%q): %s",
+				name, kv.Key, err)
+			t.Fatalf("%s: Validate() failed after h.Del(%q): %s",
+				name, kv.Key, err)
+		}
+	}
+
+	if !h.IsEmpty() {
+		t.Fatalf("%s: h.Nentries(),%d != 0 after deleting every key",
+			name, h.Nentries())
+	}
+	RunTime[name] = time.Since(StartTime[name])
+}
+
 func TestHamt64Put(t *testing.T) {
 	runTestHamt64Put(t, KVS64, Functional, TableOption)
 }
@@ -52,7 +127,7 @@ func runTestHamt64Put(
 
 	StartTime[name] = time.Now()
 	Hamt64 = hamt64.New(functional, tblOpt)
-	for _, kv := range kvs {
+	for i, kv := range kvs {
 		var k = kv.Key
 		var v = kv.Val
 
@@ -63,6 +138,8 @@ func runTestHamt64Put(
 			t.Fatalf("%s: failed to Hamt64.Put(%q, %v)", name, k, v)
 		}
 
+		validateHamt64(t, name, Hamt64, i)
+
 		var val, found = Hamt64.Get(k)
 		if !found {
 			log.Printf("%s: failed to Hamt64.Get(%q)", name, k)
@@ -76,6 +153,12 @@ func runTestHamt64Put(
 	}
 	RunTime[name] = time.Since(StartTime[name])
 
+	var err = Hamt64.Validate()
+	if err != nil {
+		log.Printf("%s: Validate() failed on full Hamt64: %s", name, err)
+		t.Fatalf("%s: Validate() failed on full Hamt64: %s", name, err)
+	}
+
 	StartTime["Hamt64.Stats()"] = time.Now()
 	var stats = Hamt64.Stats()
 	RunTime["Hamt64.Stats()"] = time.Since(StartTime["Hamt64.Stats()"])
@@ -239,7 +322,7 @@ func runTestHamt64Del(
 	}
 
 	StartTime[name] = time.Now()
-	for _, kv := range kvs {
+	for i, kv := range kvs {
 		var k = kv.Key
 		var v = kv.Val
 
@@ -255,6 +338,14 @@ func runTestHamt64Del(
 			log.Printf("%s: retrieved val,%d != expected v,%d for s=%q", name, val, v, k)
 			t.Fatalf("%s: retrieved val,%d != expected v,%d for s=%q", name, val, v, k)
 		}
+
+		validateHamt64(t, name, Hamt64, i)
+	}
+
+	var err = Hamt64.Validate()
+	if err != nil {
+		log.Printf("%s: Validate() failed on emptied Hamt64: %s", name, err)
+		t.Fatalf("%s: Validate() failed on emptied Hamt64: %s", name, err)
 	}
 	RunTime[name] = time.Since(StartTime[name])
 }
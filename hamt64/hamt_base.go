@@ -10,8 +10,11 @@ type hamtBase struct {
 	nentries   uint
 	nograde    bool
 	startFixed bool
+	obs        *observer
 }
 
+// init sets up h for the table option tblOpt. Any other option, CompactTables
+// included, leaves h a HybridTables Hamt; a HamtCompact is a different type.
 func (h *hamtBase) init(tblOpt int) {
 	// boolean zero value is false
 	switch tblOpt {
@@ -111,7 +114,8 @@ func (h *hamtBase) Get(key KeyI) (interface{}, bool) {
 		return nil, false
 	}
 
-	var hv = key.Hash()
+	var hv HashVal
+	key, hv = hashKey(key)
 	var curTable tableI = &h.root
 
 	var val interface{}
@@ -209,7 +213,9 @@ func (h *hamtBase) Range(fn func(KeyI, interface{}) bool) {
 func (h *hamtBase) Stats() *Stats {
 	var stats = new(Stats)
 
-	// statFn closes over the stats variable
+	// statFn closes over the stats variable. It must return true for leafs,
+	// because returning false stops the whole traversal, not just the descent
+	// into the node.
 	var statFn = func(n nodeI) bool {
 		var keepOn = true
 		switch x := n.(type) {
@@ -239,17 +245,19 @@ func (h *hamtBase) Stats() *Stats {
 			stats.Leafs++
 			stats.FlatLeafs++
 			stats.KeyVals += 1
-			keepOn = false
 		case *collisionLeaf:
 			stats.Nodes++
 			stats.Leafs++
 			stats.CollisionLeafs++
 			stats.KeyVals += uint(len(x.kvs))
-			keepOn = false
 		}
 		return keepOn
 	}
 
 	h.walk(statFn)
+
+	stats.Collisions.collect(&h.root, 0)
+	stats.Collisions.finish()
+
 	return stats
 }
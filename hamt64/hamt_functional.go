@@ -17,7 +17,9 @@ type HamtFunctional struct {
 // NewFunctional constructs a new HamtFunctional data structure.
 //
 // The tblOpt argument is the table option defined by the constants
-// HybridTables, SparseTables, xor FixedTables.
+// HybridTables, SparseTables, xor FixedTables. CompactTables is not a layout
+// of a HamtFunctional; it is treated as HybridTables, and Config reports
+// HybridTables. Use New or NewCompact for a HamtCompact.
 //
 func NewFunctional(tblOpt int) *HamtFunctional {
 	var h = new(HamtFunctional)
@@ -96,6 +98,12 @@ func (h *HamtFunctional) persist(oldTable, newTable tableI, path tableStack) {
 
 	if newTable == nil {
 		newParent.remove(parentIdx)
+
+		// Removing the old table may leave the parent empty, in which case the
+		// parent must be removed from its parent as well.
+		if path.len() > 0 && newParent.nentries() == 0 {
+			newParent = nil
+		}
 	} else {
 		newParent.replace(parentIdx, newTable)
 	}
@@ -124,7 +132,8 @@ func (h *HamtFunctional) Put(key KeyI, val interface{}) (Hamt, bool) {
 	var nh = new(HamtFunctional)
 	*nh = *h
 
-	var hv = key.Hash()
+	var hv HashVal
+	key, hv = hashKey(key)
 
 	var path, leaf, idx = h.find(hv)
 
@@ -132,18 +141,23 @@ func (h *HamtFunctional) Put(key KeyI, val interface{}) (Hamt, bool) {
 	var depth = uint(path.len())
 
 	var added bool
+	var oldVal interface{}
+	var events []Event // of the observer, if any
 
 	if curTable == &h.root {
 		//copying all h.root into nh.root already done in *nh = *h
 		if leaf == nil {
-			nh.root.insert(idx, newFlatLeaf(key, val))
+			nh.root.insert(idx, newFlatLeaf(hv, key, val))
 			added = true
 		} else {
 			var node nodeI
 			if leaf.Hash() == hv {
+				if h.obs != nil {
+					oldVal, _ = leaf.get(key)
+				}
 				node, added = leaf.put(key, val)
 			} else {
-				node = nh.createTable(depth+1, leaf, newFlatLeaf(key, val))
+				node = nh.createTable(depth+1, leaf, newFlatLeaf(hv, key, val))
 				added = true
 			}
 
@@ -156,20 +170,28 @@ func (h *HamtFunctional) Put(key KeyI, val interface{}) (Hamt, bool) {
 			if !nh.nograde && (curTable.nentries()+1) == UpgradeThreshold {
 				newTable = upgradeToFixedTable(
 					curTable.Hash(), depth, curTable.entries())
+
+				if h.obs != nil {
+					events = append(events,
+						tableEvent(Upgraded, newTable, depth))
+				}
 			} else {
 				newTable = curTable.copy()
 			}
 
-			newTable.insert(idx, newFlatLeaf(key, val))
+			newTable.insert(idx, newFlatLeaf(hv, key, val))
 			added = true
 		} else {
 			newTable = curTable.copy()
 
 			var node nodeI
 			if leaf.Hash() == hv {
+				if h.obs != nil {
+					oldVal, _ = leaf.get(key)
+				}
 				node, added = leaf.put(key, val)
 			} else {
-				node = nh.createTable(depth+1, leaf, newFlatLeaf(key, val))
+				node = nh.createTable(depth+1, leaf, newFlatLeaf(hv, key, val))
 				added = true
 			}
 
@@ -183,6 +205,11 @@ func (h *HamtFunctional) Put(key KeyI, val interface{}) (Hamt, bool) {
 		nh.nentries++
 	}
 
+	if h.obs != nil {
+		h.obs.deliver(append(events,
+			putEvent(key, oldVal, val, added)))
+	}
+
 	return nh, added
 }
 
@@ -200,7 +227,8 @@ func (h *HamtFunctional) Del(key KeyI) (Hamt, interface{}, bool) {
 		return h, nil, false
 	}
 
-	var hv = key.Hash()
+	var hv HashVal
+	key, hv = hashKey(key)
 	var path, leaf, idx = h.find(hv)
 
 	if leaf == nil {
@@ -213,6 +241,8 @@ func (h *HamtFunctional) Del(key KeyI) (Hamt, interface{}, bool) {
 		return h, nil, false
 	}
 
+	var events []Event // of the observer, if any
+
 	var curTable = path.pop()
 	var depth = uint(path.len())
 
@@ -242,6 +272,11 @@ func (h *HamtFunctional) Del(key KeyI) (Hamt, interface{}, bool) {
 			case !h.nograde && nents == DowngradeThreshold:
 				newTable = downgradeToSparseTable(
 					newTable.Hash(), depth, newTable.entries())
+
+				if h.obs != nil {
+					events = append(events,
+						tableEvent(Downgraded, newTable, depth))
+				}
 			}
 		} else { //leaf was a CollisionLeaf
 			newTable.replace(idx, newLeaf)
@@ -250,19 +285,26 @@ func (h *HamtFunctional) Del(key KeyI) (Hamt, interface{}, bool) {
 		nh.persist(curTable, newTable, path)
 	}
 
+	if h.obs != nil {
+		h.obs.deliver(append(events,
+			Event{Kind: Deleted, Key: key, OldVal: val}))
+	}
+
 	return nh, val, deleted
 }
 
 // String returns a simple string representation of the HamtFunctional data
 // structure.
 func (h *HamtFunctional) String() string {
-	return "HamtFunctional{" + h.hamtBase.String() + "}"
+	return "HamtFunctional{" + h.Config().String() + ", " +
+		h.hamtBase.String() + "}"
 }
 
 // LongString returns a complete recusive listing of the entire HamtFunctional
 // data structure.
 func (h *HamtFunctional) LongString(indent string) string {
-	return "HamtFunctional{\n" + indent + h.hamtBase.LongString(indent) + "\n}"
+	return "HamtFunctional{\n" + indent + h.Config().String() + "\n" +
+		indent + h.hamtBase.LongString(indent) + "\n}"
 }
 
 // walk traverses the Trie in pre-order traversal. For a Trie this is also a
@@ -283,8 +325,24 @@ func (h *HamtFunctional) Range(fn func(KeyI, interface{}) bool) {
 	h.hamtBase.Range(fn)
 }
 
+// RangeSorted executes the given function for every KeyVal pair in the Hamt in
+// the order defined by less; for example NaturalLess.
+func (h *HamtFunctional) RangeSorted(
+	less func(a, b KeyI) bool,
+	fn func(KeyI, interface{}) bool,
+) {
+	h.hamtBase.RangeSorted(less, fn)
+}
+
 // Stats walks the Hamt in a pre-order traversal and populates a Stats data
 // struture which it returns.
 func (h *HamtFunctional) Stats() *Stats {
 	return h.hamtBase.Stats()
 }
+
+// Validate walks the entire Hamt and checks every structural invariant. It
+// returns nil if the HamtFunctional is sound, otherwise an error describing the
+// first violation found.
+func (h *HamtFunctional) Validate() error {
+	return h.hamtBase.Validate()
+}
@@ -16,7 +16,9 @@ type HamtTransient struct {
 // NewTransient constructs a new HamtTransient data structure.
 //
 // The tblOpt argument is the table option defined by the constants
-// HybridTables, SparseTables, xor FixedTables.
+// HybridTables, SparseTables, xor FixedTables. CompactTables is not a layout
+// of a HamtTransient; it is treated as HybridTables, and Config reports
+// HybridTables. Use New or NewCompact for a HamtCompact.
 //
 func NewTransient(tblOpt int) *HamtTransient {
 	var h = new(HamtTransient)
@@ -79,12 +81,15 @@ func (h *HamtTransient) Get(key KeyI) (interface{}, bool) {
 func (h *HamtTransient) Put(key KeyI, val interface{}) (Hamt, bool) {
 	// Doing this in newFlatLeaf() and leafI.put().
 
-	var hv = key.Hash()
+	var hv HashVal
+	key, hv = hashKey(key)
 	var path, leaf, idx = h.find(hv)
 
 	var curTable = path.pop()
 	var depth = uint(path.len())
 	var added bool
+	var oldVal interface{}
+	var events []Event // of the observer, if any
 
 	if leaf == nil {
 		//check if upgrading allowed & if it is required
@@ -98,18 +103,26 @@ func (h *HamtTransient) Put(key KeyI, val interface{}) (Hamt, bool) {
 			parentTable.replace(parentIdx, newTable)
 
 			curTable = newTable
+
+			if h.obs != nil {
+				events = append(events,
+					tableEvent(Upgraded, newTable, depth))
+			}
 		}
-		curTable.insert(idx, newFlatLeaf(key, val))
+		curTable.insert(idx, newFlatLeaf(hv, key, val))
 		added = true
 	} else {
 		// This is the condition that allows collision leafs to exist at a level
 		// less than maxDepth. I don't know if I want to allow this...
 		if leaf.Hash() == hv {
+			if h.obs != nil {
+				oldVal, _ = leaf.get(key)
+			}
 			var newLeaf leafI
 			newLeaf, added = leaf.put(key, val)
 			curTable.replace(idx, newLeaf)
 		} else {
-			var t = h.createTable(depth+1, leaf, newFlatLeaf(key, val))
+			var t = h.createTable(depth+1, leaf, newFlatLeaf(hv, key, val))
 			curTable.replace(idx, t)
 			added = true
 		}
@@ -119,6 +132,11 @@ func (h *HamtTransient) Put(key KeyI, val interface{}) (Hamt, bool) {
 		h.nentries++
 	}
 
+	if h.obs != nil {
+		h.obs.deliver(append(events,
+			putEvent(key, oldVal, val, added)))
+	}
+
 	return h, added
 }
 
@@ -138,7 +156,8 @@ func (h *HamtTransient) Del(key KeyI) (Hamt, interface{}, bool) {
 		return h, nil, false
 	}
 
-	var hv = key.Hash()
+	var hv HashVal
+	key, hv = hashKey(key)
 	var path, leaf, idx = h.find(hv)
 
 	var curTable = path.pop()
@@ -154,6 +173,8 @@ func (h *HamtTransient) Del(key KeyI) (Hamt, interface{}, bool) {
 		return h, nil, false
 	}
 
+	var events []Event // of the observer, if any
+
 	h.nentries--
 
 	if newLeaf != nil { //leaf was a CollisionLeaf
@@ -164,7 +185,17 @@ func (h *HamtTransient) Del(key KeyI) (Hamt, interface{}, bool) {
 		// Side-Effects of removing an KeyVal from the table
 		if curTable != &h.root {
 			switch {
-			// if no entries left in table need to colapse down to parent
+			// if no entries left in table remove it from its parent; that may
+			// leave the parent empty, so keep going up the path.
+			case curTable.nentries() == 0:
+				for curTable != &h.root && curTable.nentries() == 0 {
+					var parentTable = path.pop()
+					depth--
+					parentTable.remove(hv.Index(depth))
+					curTable = parentTable
+				}
+
+			// if one leaf left in table need to colapse down to parent
 			case curTable.nentries() == 1:
 				var lastNode = curTable.entries()[0].node
 				if _, isLeaf := lastNode.(leafI); isLeaf {
@@ -181,23 +212,35 @@ func (h *HamtTransient) Del(key KeyI) (Hamt, interface{}, bool) {
 				var parentTable = path.peek()
 				var parentIdx = hv.Index(depth - 1)
 				parentTable.replace(parentIdx, newTable)
+
+				if h.obs != nil {
+					events = append(events,
+						tableEvent(Downgraded, newTable, depth))
+				}
 			}
 		}
 	}
 
+	if h.obs != nil {
+		h.obs.deliver(append(events,
+			Event{Kind: Deleted, Key: key, OldVal: val}))
+	}
+
 	return h, val, deleted
 }
 
 // String returns a simple string representation of the HamtTransient data
 // structure.
 func (h *HamtTransient) String() string {
-	return "HamtTransient{" + h.hamtBase.String() + "}"
+	return "HamtTransient{" + h.Config().String() + ", " +
+		h.hamtBase.String() + "}"
 }
 
 // LongString returns a complete recusive listing of the entire HamtTransient
 // data structure.
 func (h *HamtTransient) LongString(indent string) string {
-	return "HamtTransient{\n" + indent + h.hamtBase.LongString(indent) + "\n}"
+	return "HamtTransient{\n" + indent + h.Config().String() + "\n" +
+		indent + h.hamtBase.LongString(indent) + "\n}"
 }
 
 // walk traverses the Trie in pre-order traversal. For a Trie this is also a
@@ -218,8 +261,24 @@ func (h *HamtTransient) Range(fn func(KeyI, interface{}) bool) {
 	h.hamtBase.Range(fn)
 }
 
+// RangeSorted executes the given function for every KeyVal pair in the Hamt in
+// the order defined by less; for example NaturalLess.
+func (h *HamtTransient) RangeSorted(
+	less func(a, b KeyI) bool,
+	fn func(KeyI, interface{}) bool,
+) {
+	h.hamtBase.RangeSorted(less, fn)
+}
+
 // Stats walks the Hamt in a pre-order traversal and populates a Stats data
 // struture which it returns.
 func (h *HamtTransient) Stats() *Stats {
 	return h.hamtBase.Stats()
 }
+
+// Validate walks the entire Hamt and checks every structural invariant. It
+// returns nil if the HamtTransient is sound, otherwise an error describing the
+// first violation found.
+func (h *HamtTransient) Validate() error {
+	return h.hamtBase.Validate()
+}
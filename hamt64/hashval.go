@@ -2,35 +2,74 @@ package hamt64
 
 import (
 	"fmt"
-	"hash/fnv"
+	"math"
 	"strconv"
 	"strings"
 
 	"github.com/pkg/errors"
 )
 
-// HashVal sets the numberer of bits of the hash value by being an alias to
-// uint64 and establishes a type we can hang methods, like Index(), off of.
-type HashVal uint64
+// addUint64 adds the 8 big-endian bytes of v.
+func (h hashState) addUint64(v uint64) hashState {
+	for shift := int(56); shift >= 0; shift -= 8 {
+		h = h.addByte(byte(v >> uint(shift)))
+	}
+	return h
+}
+
+// addUint32 adds the 4 big-endian bytes of v.
+func (h hashState) addUint32(v uint32) hashState {
+	for shift := int(24); shift >= 0; shift -= 8 {
+		h = h.addByte(byte(v >> uint(shift)))
+	}
+	return h
+}
+
+func (h hashState) addBytes(bs []byte) hashState {
+	for _, b := range bs {
+		h = h.addByte(b)
+	}
+	return h
+}
 
-// CalcHash deterministically calculates a randomized uint64 of a given byte
-// slice .
-func CalcHash(bs []byte) HashVal {
-	return HashVal(fold(hash(bs), remainder))
+func (h hashState) addString(s string) hashState {
+	for i := 0; i < len(s); i++ {
+		h = h.addByte(s[i])
+	}
+	return h
 }
 
-func hash(bs []byte) uint64 {
-	var h = fnv.New64()
-	h.Write(bs)
-	return h.Sum64()
+// hashUint64 returns the same HashVal as CalcHash of the 8 big-endian bytes of
+// v, without allocating a byte slice.
+func hashUint64(v uint64) HashVal {
+	return newHashState().addUint64(v).sum()
 }
 
-func mask(size uint) uint64 {
-	return uint64(1<<size) - 1
+// hashUint32 returns the same HashVal as CalcHash of the 4 big-endian bytes of
+// v, without allocating a byte slice.
+func hashUint32(v uint32) HashVal {
+	return newHashState().addUint32(v).sum()
 }
 
-func fold(hash uint64, rem uint) uint64 {
-	return (hash >> (hashSize - rem)) ^ (hash & mask(hashSize-rem))
+// mix64 is the MurmurHash3 64 bit finalizer. Every bit of v affects every bit
+// of the result. FNV only carries bits from low to high, so it is applied to
+// values whose varying bits are all in the high bytes, like float64 bits.
+func mix64(v uint64) uint64 {
+	v ^= v >> 33
+	v *= 0xff51afd7ed558ccd
+	v ^= v >> 33
+	v *= 0xc4ceb9fe1a85ec53
+	v ^= v >> 33
+	return v
+}
+
+// floatBits returns the mixed bits of f. 0.0 and -0.0 are ==, so they get the
+// same bits.
+func floatBits(f float64) uint64 {
+	if f == 0 {
+		f = 0
+	}
+	return mix64(math.Float64bits(f))
 }
 
 func indexMask(depth uint) HashVal {
@@ -68,9 +107,9 @@ func (hv HashVal) hashPath(depth uint) HashVal {
 // buildHashPath method adds a idx at depth level of the hashPath. Given a
 // hash Path = "/11/07/13" and you call hashPath.buildHashPath(23, 3) the method
 // will return hashPath "/11/07/13/23". hashPath is shown here in the string
-// representation, but the real value is HashVal (aka uint64).
+// representation, but the real value is a HashVal.
 func (hv HashVal) buildHashPath(idx, depth uint) HashVal {
-	_ = assertOn && assert(idx < DepthLimit, "buildHashPath: idx > maxIndex")
+	_ = assertOn && assert(idx < IndexLimit, "buildHashPath: idx > maxIndex")
 
 	hv &= hashPathMask(depth)
 	return hv | HashVal(idx<<(depth*NumIndexBits))
@@ -125,34 +164,42 @@ func (hv HashVal) String() string {
 	return hv.HashPathString(DepthLimit)
 }
 
-// parseHashPath
-func parseHashPath(s string) (HashVal, error) {
+// ParseHashPath parses the HashPathString form of a hash path, for example
+// "/03/17", and returns the hash path and its depth, the number of indexes in
+// it. The path "/" has depth 0.
+func ParseHashPath(s string) (HashVal, uint, error) {
 	if !strings.HasPrefix(s, "/") {
-		return 0, errors.Errorf(
-			"parseHashPath: input, %q, does not start with '/'", s)
+		return 0, 0, errors.Errorf(
+			"ParseHashPath: input, %q, does not start with '/'", s)
 	}
 
 	if len(s) == 1 { // s="/"
-		return 0, nil
+		return 0, 0, nil
 	}
 
 	if strings.HasSuffix(s, "/") {
-		return 0, errors.Errorf("parseHashPath: input, %q, ends with '/'", s)
+		return 0, 0, errors.Errorf("ParseHashPath: input, %q, ends with '/'", s)
 	}
 	var s0 = s[1:] //take the leading '/' off
 	var idxStrs = strings.Split(s0, "/")
 
+	if len(idxStrs) > int(DepthLimit) {
+		return 0, 0, errors.Errorf(
+			"ParseHashPath: input, %q, has more than DepthLimit,%d indexes",
+			s, DepthLimit)
+	}
+
 	var hv HashVal
 	for i, idxStr := range idxStrs {
 		var idx, err = strconv.ParseUint(idxStr, 10, int(NumIndexBits))
 		if err != nil {
-			return 0, errors.Wrapf(err,
-				"parseHashPath: the %d'th index string failed to parse.", i)
+			return 0, 0, errors.Wrapf(err,
+				"ParseHashPath: the %d'th index string failed to parse.", i)
 		}
 
 		//hv |= HashVal(idx << (uint(i) * NumIndexBits))
 		hv = hv.buildHashPath(uint(idx), uint(i))
 	}
 
-	return hv, nil
+	return hv, uint(len(idxStrs)), nil
 }
@@ -0,0 +1,51 @@
+package hamt64
+
+// This file holds everything that depends on the width of HashVal. The rest
+// of the package is the same for hamt32 and hamt64, and is the template hamt32 is
+// generated from; see internal/hamtgen.
+
+// HashVal sets the numberer of bits of the hash value by being an alias to
+// uint64 and establishes a type we can hang methods, like Index(), off of.
+type HashVal uint64
+
+// CalcHash deterministically calculates a randomized uint64 of a given byte
+// slice . It is the FNV-1 hash of hash/fnv.New64() folded down to
+// DepthLimit*NumIndexBits bits, calculated without any allocations.
+func CalcHash(bs []byte) HashVal {
+	return newHashState().addBytes(bs).sum()
+}
+
+// hasherName is the Hasher of a Config.
+const hasherName = "FNV-1 64"
+
+// The FNV-1 parameters used by hash/fnv.New64().
+const (
+	fnvOffset64 uint64 = 14695981039346656037
+	fnvPrime64  uint64 = 1099511628211
+)
+
+// hashState is the running state of an inline FNV-1 hash. It computes the
+// same hash as hash/fnv.New64() without allocating a hasher, and lets strings
+// be hashed without converting them to a byte slice.
+type hashState uint64
+
+func newHashState() hashState {
+	return hashState(fnvOffset64)
+}
+
+func (h hashState) addByte(b byte) hashState {
+	return (h * hashState(fnvPrime64)) ^ hashState(b)
+}
+
+// sum folds the hash state down to a HashVal.
+func (h hashState) sum() HashVal {
+	return HashVal(fold(uint64(h), remainder))
+}
+
+func mask(size uint) uint64 {
+	return uint64(1<<size) - 1
+}
+
+func fold(hash uint64, rem uint) uint64 {
+	return (hash >> (hashSize - rem)) ^ (hash & mask(hashSize-rem))
+}
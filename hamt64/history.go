@@ -0,0 +1,145 @@
+package hamt64
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// History records successive versions of a HamtFunctional. Each recorded
+// version gets an ID one greater than the last, starting at 1, and an optional
+// label. Because the versions of a HamtFunctional share every table they have
+// in common, keeping many versions costs little more than keeping one.
+//
+// A History may be bounded; once it holds its limit of versions, recording
+// another drops the oldest.
+//
+// A History is safe for concurrent use.
+type History struct {
+	mu       sync.RWMutex
+	limit    int
+	lastID   uint64
+	versions []VersionInfo // oldest first
+}
+
+// VersionInfo describes one version recorded in a History.
+type VersionInfo struct {
+	ID    uint64
+	Label string
+	Hamt  *HamtFunctional
+}
+
+// NewHistory constructs an empty History retaining at most limit versions. A
+// limit of zero or less means every version is retained.
+func NewHistory(limit int) *History {
+	return &History{limit: limit}
+}
+
+// Record adds h as the newest version of the History and returns its ID. The
+// label may be empty.
+func (hi *History) Record(h *HamtFunctional, label string) uint64 {
+	hi.mu.Lock()
+	defer hi.mu.Unlock()
+
+	hi.lastID++
+	hi.versions = append(hi.versions, VersionInfo{hi.lastID, label, h})
+
+	if hi.limit > 0 && len(hi.versions) > hi.limit {
+		var drop = len(hi.versions) - hi.limit
+		for i := 0; i < drop; i++ {
+			hi.versions[i] = VersionInfo{} // let the Hamt be collected
+		}
+		hi.versions = hi.versions[drop:]
+	}
+
+	return hi.lastID
+}
+
+// find returns the index of version id in hi.versions. The caller must hold
+// hi.mu.
+func (hi *History) find(id uint64) (int, error) {
+	if len(hi.versions) == 0 {
+		return 0, errors.Errorf("version %d not retained; History is empty", id)
+	}
+	// IDs are consecutive, so the index is a subtraction away.
+	var oldest = hi.versions[0].ID
+	if id < oldest || id > hi.lastID {
+		return 0, errors.Errorf("version %d not retained; have %d through %d",
+			id, oldest, hi.lastID)
+	}
+	return int(id - oldest), nil
+}
+
+// At returns the Hamt recorded as version id.
+func (hi *History) At(id uint64) (*HamtFunctional, error) {
+	hi.mu.RLock()
+	defer hi.mu.RUnlock()
+
+	var i, err = hi.find(id)
+	if err != nil {
+		return nil, errors.Wrap(err, "At")
+	}
+	return hi.versions[i].Hamt, nil
+}
+
+// Latest returns the newest version. It returns false if the History is
+// empty.
+func (hi *History) Latest() (VersionInfo, bool) {
+	hi.mu.RLock()
+	defer hi.mu.RUnlock()
+
+	if len(hi.versions) == 0 {
+		return VersionInfo{}, false
+	}
+	return hi.versions[len(hi.versions)-1], true
+}
+
+// Labeled returns the newest retained version with the given label. It returns
+// false if there is none.
+func (hi *History) Labeled(label string) (VersionInfo, bool) {
+	hi.mu.RLock()
+	defer hi.mu.RUnlock()
+
+	for i := len(hi.versions) - 1; i >= 0; i-- {
+		if hi.versions[i].Label == label {
+			return hi.versions[i], true
+		}
+	}
+	return VersionInfo{}, false
+}
+
+// Versions returns every retained version, oldest first.
+func (hi *History) Versions() []VersionInfo {
+	hi.mu.RLock()
+	defer hi.mu.RUnlock()
+
+	var vs = make([]VersionInfo, len(hi.versions))
+	copy(vs, hi.versions)
+	return vs
+}
+
+// Diff returns the changes between the retained versions from and to. See the
+// Diff function.
+func (hi *History) Diff(from, to uint64) ([]Event, error) {
+	var a, err = hi.At(from)
+	if err != nil {
+		return nil, errors.Wrap(err, "History.Diff")
+	}
+	var b *HamtFunctional
+	b, err = hi.At(to)
+	if err != nil {
+		return nil, errors.Wrap(err, "History.Diff")
+	}
+	return Diff(a, b)
+}
+
+// Rollback records the retained version id again as the newest version, with
+// the given label, and returns it and its new ID. Nothing is discarded; the
+// versions after id remain in the History.
+func (hi *History) Rollback(id uint64, label string) (*HamtFunctional, uint64, error) {
+	var h, err = hi.At(id)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "Rollback")
+	}
+	return h, hi.Record(h, label), nil
+}
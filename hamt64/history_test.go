@@ -0,0 +1,125 @@
+package hamt64_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/lleo/go-hamt/hamt64"
+)
+
+func TestHamt64History(t *testing.T) {
+	var name = "TestHamt64History"
+	var kvs = KVS64[:1000]
+
+	var hist = hamt64.NewHistory(0)
+	var h = hamt64.NewFunctional(TableOption)
+	var ids []uint64
+	for i, kv := range kvs {
+		var nh, _ = h.Put(kv.Key, kv.Val)
+		h = nh.(*hamt64.HamtFunctional)
+		var label string
+		if i%100 == 99 {
+			label = fmt.Sprint("after ", i+1)
+		}
+		ids = append(ids, hist.Record(h, label))
+	}
+
+	for i, id := range ids {
+		if id != uint64(i+1) {
+			t.Fatalf("%s: version %d got ID %d", name, i, id)
+		}
+		var v, err = hist.At(id)
+		if err != nil {
+			t.Fatalf("%s: At(%d) failed: %s", name, id, err)
+		}
+		if v.Nentries() != uint(i+1) {
+			t.Fatalf("%s: At(%d).Nentries(),%d != %d", name, id, v.Nentries(), i+1)
+		}
+		if _, found := v.Get(kvs[i].Key); !found {
+			t.Fatalf("%s: At(%d) lacks %s", name, id, kvs[i].Key)
+		}
+		if i+1 < len(kvs) {
+			if _, found := v.Get(kvs[i+1].Key); found {
+				t.Fatalf("%s: At(%d) holds later key %s", name, id, kvs[i+1].Key)
+			}
+		}
+	}
+
+	var vi, found = hist.Labeled("after 500")
+	if !found || vi.ID != 500 || vi.Hamt.Nentries() != 500 {
+		t.Fatalf("%s: Labeled(\"after 500\") = %d, %t", name, vi.ID, found)
+	}
+
+	var events, err = hist.Diff(500, 600)
+	if err != nil {
+		t.Fatalf("%s: Diff(500, 600) failed: %s", name, err)
+	}
+	if len(events) != 100 {
+		t.Fatalf("%s: Diff(500, 600) returned %d Events", name, len(events))
+	}
+	for _, e := range events {
+		if e.Kind != hamt64.Inserted {
+			t.Fatalf("%s: Diff(500, 600) reported %s %s", name, e.Kind, e.Key)
+		}
+	}
+
+	// Rollback records an old version as the newest.
+	var rb, rbID, _ = hist.Rollback(10, "undo")
+	if rbID != uint64(len(kvs)+1) || rb.Nentries() != 10 {
+		t.Fatalf("%s: Rollback(10) = %d entries as version %d",
+			name, rb.Nentries(), rbID)
+	}
+	var latest, _ = hist.Latest()
+	if latest.ID != rbID || latest.Label != "undo" || latest.Hamt != rb {
+		t.Fatalf("%s: Latest() = %d %q", name, latest.ID, latest.Label)
+	}
+	events, _ = hist.Diff(10, rbID)
+	if len(events) != 0 {
+		t.Fatalf("%s: Diff(10, rollback) returned %d Events", name, len(events))
+	}
+
+	if _, err = hist.At(0); err == nil {
+		t.Fatalf("%s: At(0) did not fail", name)
+	}
+	if _, err = hist.At(rbID + 1); err == nil {
+		t.Fatalf("%s: At(%d) did not fail", name, rbID+1)
+	}
+}
+
+func TestHamt64HistoryRetention(t *testing.T) {
+	var name = "TestHamt64HistoryRetention"
+
+	var hist = hamt64.NewHistory(10)
+	if _, found := hist.Latest(); found {
+		t.Fatalf("%s: empty History has a Latest version", name)
+	}
+	if _, err := hist.At(1); err == nil {
+		t.Fatalf("%s: At(1) of an empty History did not fail", name)
+	}
+
+	var h = hamt64.NewFunctional(TableOption)
+	for i, kv := range KVS64[:25] {
+		var nh, _ = h.Put(kv.Key, kv.Val)
+		h = nh.(*hamt64.HamtFunctional)
+		hist.Record(h, fmt.Sprint(i))
+	}
+
+	var vs = hist.Versions()
+	if len(vs) != 10 || vs[0].ID != 16 || vs[9].ID != 25 {
+		t.Fatalf("%s: retained %d versions %d through %d",
+			name, len(vs), vs[0].ID, vs[len(vs)-1].ID)
+	}
+	if _, err := hist.At(15); err == nil {
+		t.Fatalf("%s: At(15) of a dropped version did not fail", name)
+	}
+	if _, err := hist.Diff(15, 25); err == nil {
+		t.Fatalf("%s: Diff(15, 25) with a dropped version did not fail", name)
+	}
+	if _, found := hist.Labeled("3"); found {
+		t.Fatalf("%s: Labeled found a dropped version", name)
+	}
+	var v, err = hist.At(16)
+	if err != nil || v.Nentries() != 16 {
+		t.Fatalf("%s: At(16) = %v, %v", name, v, err)
+	}
+}
@@ -0,0 +1,451 @@
+package hamt64
+
+import (
+	"bufio"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"math"
+
+	"github.com/pkg/errors"
+)
+
+// An Image is a read-only Hamt stored in a compact binary layout, usually a
+// memory mapped file written by WriteImage. Get, Range, and Stats work
+// directly on the image; nothing is decoded ahead of time and no tables or
+// leafs are allocated, so opening even a very large image is instant and costs
+// no heap.
+//
+// Keys and values are byte slices. The slices returned by Get and passed to
+// the Range callback point into the image; they must not be modified, and
+// must not be used after Close.
+//
+// The layout, all integers little endian, is the magic imageMagic followed
+// by the nodes, children before their parents, and a trailer:
+//
+//	table:   kind byte (imageTable), depth byte, 2 unused bytes,
+//	         uint32 bitmap of occupied indexes,
+//	         uint32 offset of each child in index order
+//	leaf:    kind byte (imageLeaf), 3 unused bytes, uint32 number of pairs,
+//	         uint64 HashVal, then per pair uint32 key length, uint32 value
+//	         length, key, value
+//	trailer: uint32 offset of the root table, uint32 CRC-32C of
+//	         everything before the trailer, uint64 number of KeyVal pairs
+//
+// Offsets are from the start of the image, so an image is at most 4GiB.
+type Image struct {
+	data     []byte
+	root     uint32
+	nentries uint64
+	unmap    func() error
+}
+
+const imageMagic = "HAMTIMG1"
+
+const imageTrailerSize = 16
+
+var imageCRCTable = crc32.MakeTable(crc32.Castagnoli)
+
+const (
+	imageTable byte = 1
+	imageLeaf  byte = 2
+)
+
+const (
+	imageTableHeaderSize = 8
+	imageLeafHeaderSize  = 16
+)
+
+// NewImage returns the Image stored in data. It checks the magic and trailer
+// only; use Validate to check the whole image.
+func NewImage(data []byte) (*Image, error) {
+	if len(data) < len(imageMagic)+imageTrailerSize ||
+		string(data[:len(imageMagic)]) != imageMagic {
+		return nil, errors.New("NewImage: not a Hamt image")
+	}
+
+	var trailer = data[len(data)-imageTrailerSize:]
+	var im = &Image{
+		data:     data,
+		root:     binary.LittleEndian.Uint32(trailer[0:]),
+		nentries: binary.LittleEndian.Uint64(trailer[8:]),
+	}
+
+	var end = uint64(len(data) - imageTrailerSize)
+	if uint64(im.root)+imageTableHeaderSize > end || data[im.root] != imageTable {
+		return nil, errors.Errorf("NewImage: bad root table offset %d", im.root)
+	}
+
+	return im, nil
+}
+
+// Close releases the memory mapping of an Image returned by OpenImage. It does
+// nothing for an Image returned by NewImage.
+func (im *Image) Close() error {
+	if im.unmap == nil {
+		return nil
+	}
+	var err = im.unmap()
+	im.unmap = nil
+	im.data = nil
+	return err
+}
+
+// IsEmpty returns true if the Image holds no KeyVal pairs.
+func (im *Image) IsEmpty() bool {
+	return im.nentries == 0
+}
+
+// Nentries returns the number of KeyVal pairs in the Image.
+func (im *Image) Nentries() uint {
+	return uint(im.nentries)
+}
+
+func (im *Image) u32(off uint32) uint32 {
+	return binary.LittleEndian.Uint32(im.data[off:])
+}
+
+// child returns the offset of the child at idx of the table at off, and false
+// if that slot is empty.
+func (im *Image) child(off uint32, idx uint) (uint32, bool) {
+	var bm = im.u32(off + 4)
+	var bit = uint32(1) << idx
+	if bm&bit == 0 {
+		return 0, false
+	}
+	var pos = bitCount32(bm & (bit - 1))
+	return im.u32(off + imageTableHeaderSize + 4*uint32(pos)), true
+}
+
+// leafRange calls fn for every KeyVal pair of the leaf at off.
+func (im *Image) leafRange(off uint32, fn func(key, val []byte) bool) bool {
+	var n = im.u32(off + 4)
+	var p = off + imageLeafHeaderSize
+	for i := uint32(0); i < n; i++ {
+		var klen, vlen = im.u32(p), im.u32(p + 4)
+		p += 8
+		var key = im.data[p : p+klen : p+klen]
+		p += klen
+		var val = im.data[p : p+vlen : p+vlen]
+		p += vlen
+		if !fn(key, val) {
+			return false
+		}
+	}
+	return true
+}
+
+// Get retrieves the value related to the key in the Image. It also returns a
+// bool to indicate the value was found.
+func (im *Image) Get(key []byte) ([]byte, bool) {
+	var hv = CalcHash(key)
+
+	var off = im.root
+	for depth := uint(0); depth <= maxDepth; depth++ {
+		var next, found = im.child(off, hv.Index(depth))
+		if !found {
+			return nil, false
+		}
+		off = next
+
+		if im.data[off] == imageLeaf {
+			if HashVal(binary.LittleEndian.Uint64(im.data[off+8:])) != hv {
+				return nil, false
+			}
+			var val []byte
+			im.leafRange(off, func(k, v []byte) bool {
+				if string(k) == string(key) {
+					val, found = v, true
+					return false
+				}
+				return true
+			})
+			return val, found
+		}
+	}
+
+	return nil, false
+}
+
+// Range executes the given function for every KeyVal pair in the Image, in
+// the same order Range visits them in the Hamt the Image was written from.
+func (im *Image) Range(fn func(key, val []byte) bool) {
+	im.walk(im.root, func(off uint32, _ uint) bool {
+		if im.data[off] == imageLeaf {
+			return im.leafRange(off, fn)
+		}
+		return true
+	})
+}
+
+// walk calls fn for the node at off and every node below it in pre-order,
+// with the depth of each table. It returns false if fn stopped the walk.
+func (im *Image) walk(off uint32, fn func(off uint32, depth uint) bool) bool {
+	if !fn(off, uint(im.data[off+1])) {
+		return false
+	}
+	if im.data[off] != imageTable {
+		return true
+	}
+	var n = bitCount32(im.u32(off + 4))
+	for i := uint(0); i < n; i++ {
+		var child = im.u32(off + imageTableHeaderSize + 4*uint32(i))
+		if !im.walk(child, fn) {
+			return false
+		}
+	}
+	return true
+}
+
+// Stats walks the Image and populates a Stats data structure which it
+// returns. Every table of an Image is counted as a SparseTable; there are no
+// empty slots, so Nils is zero.
+func (im *Image) Stats() *Stats {
+	var stats = new(Stats)
+
+	im.walk(im.root, func(off uint32, depth uint) bool {
+		stats.Nodes++
+		switch im.data[off] {
+		case imageTable:
+			stats.Tables++
+			stats.SparseTables++
+			stats.TableCountsByNentries[bitCount32(im.u32(off+4))]++
+			stats.TableCountsByDepth[depth]++
+			if depth > stats.MaxDepth {
+				stats.MaxDepth = depth
+			}
+		case imageLeaf:
+			stats.Leafs++
+			var n = im.u32(off + 4)
+			if n == 1 {
+				stats.FlatLeafs++
+			} else {
+				stats.CollisionLeafs++
+			}
+			stats.KeyVals += uint(n)
+		}
+		return true
+	})
+
+	return stats
+}
+
+// Validate checks the checksum of the Image, that every offset and length in
+// it is in bounds, that every table is at the right depth, that every key
+// hashes to the path it is stored at, and that the number of KeyVal pairs
+// matches the trailer.
+// Get, Range, and Stats assume a valid Image; run Validate on images from an
+// untrusted source before using them.
+func (im *Image) Validate() error {
+	var end = uint64(len(im.data) - imageTrailerSize)
+	var nkvs uint64
+
+	var sum = binary.LittleEndian.Uint32(im.data[end+4:])
+	if crc32.Checksum(im.data[:end], imageCRCTable) != sum {
+		return errors.New("Validate: checksum mismatch")
+	}
+
+	var check func(off uint32, hashPath HashVal, depth uint) error
+	check = func(off uint32, hashPath HashVal, depth uint) error {
+		if uint64(off) < uint64(len(imageMagic)) ||
+			uint64(off)+imageTableHeaderSize > end {
+			return errors.Errorf("node offset %d out of bounds", off)
+		}
+		switch im.data[off] {
+		case imageTable:
+			if uint(im.data[off+1]) != depth || depth > maxDepth {
+				return errors.Errorf("table at %s has depth %d",
+					hashPath.HashPathString(depth), im.data[off+1])
+			}
+			var bm = im.u32(off + 4)
+			var n = uint64(bitCount32(bm))
+			if uint64(off)+imageTableHeaderSize+4*n > end {
+				return errors.Errorf("table at %s out of bounds",
+					hashPath.HashPathString(depth))
+			}
+			if depth > 0 && n == 0 {
+				return errors.Errorf("empty table at %s",
+					hashPath.HashPathString(depth))
+			}
+			for idx := uint(0); idx < IndexLimit; idx++ {
+				if child, found := im.child(off, idx); found {
+					var err = check(child, hashPath.buildHashPath(idx, depth),
+						depth+1)
+					if err != nil {
+						return err
+					}
+				}
+			}
+		case imageLeaf:
+			if depth == 0 {
+				return errors.New("root is not a table")
+			}
+			if uint64(off)+imageLeafHeaderSize > end {
+				return errors.Errorf("leaf at %s out of bounds",
+					hashPath.HashPathString(depth))
+			}
+			var hv = HashVal(binary.LittleEndian.Uint64(im.data[off+8:]))
+			if hv&hashPathMask(depth) != hashPath {
+				return errors.Errorf("leaf with hash %s stored at %s",
+					hv, hashPath.HashPathString(depth))
+			}
+			var n = im.u32(off + 4)
+			if n == 0 {
+				return errors.Errorf("empty leaf at %s",
+					hashPath.HashPathString(depth))
+			}
+			var p = uint64(off) + imageLeafHeaderSize
+			for i := uint32(0); i < n; i++ {
+				if p+8 > end {
+					return errors.Errorf("leaf at %s out of bounds",
+						hashPath.HashPathString(depth))
+				}
+				var klen = uint64(im.u32(uint32(p)))
+				var vlen = uint64(im.u32(uint32(p + 4)))
+				p += 8
+				if p+klen+vlen > end {
+					return errors.Errorf("leaf at %s out of bounds",
+						hashPath.HashPathString(depth))
+				}
+				if CalcHash(im.data[p:p+klen]) != hv {
+					return errors.Errorf("key %q does not hash to %s",
+						im.data[p:p+klen], hv)
+				}
+				p += klen + vlen
+			}
+			nkvs += uint64(n)
+		default:
+			return errors.Errorf("unknown node kind %d at offset %d",
+				im.data[off], off)
+		}
+		return nil
+	}
+
+	var err = check(im.root, 0, 0)
+	if err != nil {
+		return errors.Wrap(err, "Validate")
+	}
+	if nkvs != im.nentries {
+		return errors.Errorf("Validate: trailer says %d KeyVal pairs; found %d",
+			im.nentries, nkvs)
+	}
+	return nil
+}
+
+// WriteImage writes h to w in the Image layout. Every key of h must be a
+// ByteSliceKey or StringKey, and every value a []byte or string.
+//
+// The tables and leafs of the image mirror those of h, so no key is rehashed
+// and the Image has the same shape as h.
+func WriteImage(w io.Writer, h Hamt) error {
+	var hb, err = baseOf(h)
+	if err != nil {
+		return errors.Wrap(err, "WriteImage")
+	}
+
+	var iw = &imageWriter{w: bufio.NewWriter(w)}
+	iw.write([]byte(imageMagic))
+
+	var root = iw.node(&hb.root, 0)
+
+	var trailer [imageTrailerSize]byte
+	binary.LittleEndian.PutUint32(trailer[0:], root)
+	binary.LittleEndian.PutUint32(trailer[4:], iw.sum)
+	binary.LittleEndian.PutUint64(trailer[8:], uint64(hb.nentries))
+	iw.write(trailer[:])
+
+	if iw.err == nil {
+		iw.err = iw.w.Flush()
+	}
+	return errors.Wrap(iw.err, "WriteImage")
+}
+
+type imageWriter struct {
+	w   *bufio.Writer
+	off uint64
+	sum uint32 // CRC-32C of everything written
+	err error
+}
+
+func (iw *imageWriter) write(b []byte) {
+	if iw.err != nil {
+		return
+	}
+	if iw.off+uint64(len(b)) > math.MaxUint32 {
+		iw.err = errors.New("image larger than 4GiB")
+		return
+	}
+	_, iw.err = iw.w.Write(b)
+	iw.off += uint64(len(b))
+	iw.sum = crc32.Update(iw.sum, imageCRCTable, b)
+}
+
+func (iw *imageWriter) u32(v uint32) {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], v)
+	iw.write(b[:])
+}
+
+// node writes n, after its children, and returns its offset.
+func (iw *imageWriter) node(n nodeI, depth uint) uint32 {
+	switch x := n.(type) {
+	case tableI:
+		var ents = x.entries()
+		var offs = make([]uint32, len(ents))
+		var bm uint32
+		for i, ent := range ents {
+			offs[i] = iw.node(ent.node, depth+1)
+			bm |= 1 << ent.idx
+		}
+
+		var off = uint32(iw.off)
+		iw.write([]byte{imageTable, byte(depth), 0, 0})
+		iw.u32(bm)
+		for _, o := range offs {
+			iw.u32(o)
+		}
+		return off
+
+	case leafI:
+		var kvs = x.keyVals()
+		var off = uint32(iw.off)
+		iw.write([]byte{imageLeaf, 0, 0, 0})
+		iw.u32(uint32(len(kvs)))
+		var hv [8]byte
+		binary.LittleEndian.PutUint64(hv[:], uint64(x.Hash()))
+		iw.write(hv[:])
+
+		for _, kv := range kvs {
+			var key, val []byte
+			switch k := kv.Key.(type) {
+			case ByteSliceKey:
+				key = k
+			case StringKey:
+				key = []byte(k)
+			default:
+				if iw.err == nil {
+					iw.err = errors.Errorf("key %v of type %T is not a "+
+						"ByteSliceKey or StringKey", kv.Key, kv.Key)
+				}
+			}
+			switch v := kv.Val.(type) {
+			case []byte:
+				val = v
+			case string:
+				val = []byte(v)
+			default:
+				if iw.err == nil {
+					iw.err = errors.Errorf("value of key %v of type %T is "+
+						"not a []byte or string", kv.Key, kv.Val)
+				}
+			}
+			iw.u32(uint32(len(key)))
+			iw.u32(uint32(len(val)))
+			iw.write(key)
+			iw.write(val)
+		}
+		return off
+	}
+
+	return 0
+}
@@ -0,0 +1,47 @@
+// +build linux darwin freebsd netbsd openbsd dragonfly
+
+package hamt64
+
+import (
+	"os"
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+// OpenImage memory maps the image file written by WriteImage at path and
+// returns it as an Image. Close the Image to unmap the file.
+func OpenImage(path string) (*Image, error) {
+	var f, err = os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "OpenImage")
+	}
+	defer f.Close()
+
+	var fi os.FileInfo
+	fi, err = f.Stat()
+	if err != nil {
+		return nil, errors.Wrap(err, "OpenImage")
+	}
+	var size = fi.Size()
+	if size < int64(len(imageMagic)+imageTrailerSize) || size != int64(int(size)) {
+		return nil, errors.Errorf("OpenImage: %s: bad size %d", path, size)
+	}
+
+	var data []byte
+	data, err = syscall.Mmap(int(f.Fd()), 0, int(size),
+		syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, errors.Wrapf(err, "OpenImage: %s: mmap", path)
+	}
+
+	var im *Image
+	im, err = NewImage(data)
+	if err != nil {
+		syscall.Munmap(data)
+		return nil, errors.Wrapf(err, "OpenImage: %s", path)
+	}
+	im.unmap = func() error { return syscall.Munmap(data) }
+
+	return im, nil
+}
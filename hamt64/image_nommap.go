@@ -0,0 +1,27 @@
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd,!dragonfly
+
+package hamt64
+
+import (
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+)
+
+// OpenImage reads the image file written by WriteImage at path and returns it
+// as an Image. On this platform the file is read into memory rather than
+// memory mapped.
+func OpenImage(path string) (*Image, error) {
+	var data, err = ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "OpenImage")
+	}
+
+	var im *Image
+	im, err = NewImage(data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "OpenImage: %s", path)
+	}
+
+	return im, nil
+}
@@ -0,0 +1,170 @@
+package hamt64_test
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lleo/go-hamt/hamt64"
+)
+
+// buildByteHamt64 builds a Hamt with ByteSliceKey keys and []byte values.
+func buildByteHamt64(n int) hamt64.Hamt {
+	var h = hamt64.New(Functional, TableOption)
+	for _, kv := range KVS64[:n] {
+		var k = hamt64.ByteSliceKey(kv.Key.(hamt64.StringKey))
+		h, _ = h.Put(k, []byte(fmt.Sprint("val ", kv.Val)))
+	}
+	return h
+}
+
+func TestHamt64Image(t *testing.T) {
+	var name = "TestHamt64Image"
+
+	for _, n := range []int{0, 1, 1000, 50000} {
+		var h = buildByteHamt64(n)
+		h, _ = h.Put(hamt64.StringKey("a string key"), "a string value")
+
+		var buf bytes.Buffer
+		var err = hamt64.WriteImage(&buf, h)
+		if err != nil {
+			t.Fatalf("%s: WriteImage failed: %s", name, err)
+		}
+
+		var im *hamt64.Image
+		im, err = hamt64.NewImage(buf.Bytes())
+		if err != nil {
+			t.Fatalf("%s: NewImage failed: %s", name, err)
+		}
+		if err = im.Validate(); err != nil {
+			t.Fatalf("%s: n=%d: Validate failed: %s", name, n, err)
+		}
+		if im.Nentries() != h.Nentries() {
+			t.Fatalf("%s: Nentries(),%d != %d", name, im.Nentries(), h.Nentries())
+		}
+
+		h.Range(func(k hamt64.KeyI, v interface{}) bool {
+			var key, val []byte
+			if sk, ok := k.(hamt64.StringKey); ok {
+				key, val = []byte(sk), []byte(v.(string))
+			} else {
+				key, val = k.(hamt64.ByteSliceKey), v.([]byte)
+			}
+			var got, found = im.Get(key)
+			if !found || !bytes.Equal(got, val) {
+				t.Fatalf("%s: Get(%q) = %q, %t; expected %q",
+					name, key, got, found, val)
+			}
+			return true
+		})
+		if _, found := im.Get([]byte("not a key")); found {
+			t.Fatalf("%s: Get found a missing key", name)
+		}
+
+		// Range visits the same pairs in the same order as the Hamt.
+		var keys [][]byte
+		h.Range(func(k hamt64.KeyI, _ interface{}) bool {
+			if sk, ok := k.(hamt64.StringKey); ok {
+				keys = append(keys, []byte(sk))
+			} else {
+				keys = append(keys, k.(hamt64.ByteSliceKey))
+			}
+			return true
+		})
+		var i int
+		im.Range(func(k, _ []byte) bool {
+			if !bytes.Equal(k, keys[i]) {
+				t.Fatalf("%s: Range key %d = %q; expected %q", name, i, k, keys[i])
+			}
+			i++
+			return true
+		})
+		if i != len(keys) {
+			t.Fatalf("%s: Range visited %d pairs; expected %d", name, i, len(keys))
+		}
+
+		var hs, is = h.Stats(), im.Stats()
+		if is.Tables != hs.Tables || is.Leafs != hs.Leafs ||
+			is.KeyVals != hs.KeyVals || is.MaxDepth != hs.MaxDepth ||
+			is.TableCountsByDepth != hs.TableCountsByDepth {
+			t.Fatalf("%s: Image Stats %+v differ from Hamt Stats %+v",
+				name, is, hs)
+		}
+	}
+}
+
+func TestHamt64OpenImage(t *testing.T) {
+	var name = "TestHamt64OpenImage"
+
+	var dir, err = ioutil.TempDir("", "hamt64-image")
+	if err != nil {
+		t.Fatalf("%s: TempDir failed: %s", name, err)
+	}
+	defer os.RemoveAll(dir)
+
+	var h = buildByteHamt64(10000)
+	var path = filepath.Join(dir, "image")
+	var f *os.File
+	f, err = os.Create(path)
+	if err != nil {
+		t.Fatalf("%s: Create failed: %s", name, err)
+	}
+	err = hamt64.WriteImage(f, h)
+	f.Close()
+	if err != nil {
+		t.Fatalf("%s: WriteImage failed: %s", name, err)
+	}
+
+	var im *hamt64.Image
+	im, err = hamt64.OpenImage(path)
+	if err != nil {
+		t.Fatalf("%s: OpenImage failed: %s", name, err)
+	}
+	if err = im.Validate(); err != nil {
+		t.Fatalf("%s: Validate failed: %s", name, err)
+	}
+	var key = []byte(KVS64[1234].Key.(hamt64.StringKey))
+	if _, found := im.Get(key); !found {
+		t.Fatalf("%s: Get(%q) failed", name, key)
+	}
+	if err = im.Close(); err != nil {
+		t.Fatalf("%s: Close failed: %s", name, err)
+	}
+}
+
+func TestHamt64ImageErrors(t *testing.T) {
+	var name = "TestHamt64ImageErrors"
+
+	var h = hamt64.New(Functional, TableOption)
+	h, _ = h.Put(hamt64.Int64Key(1), []byte("v"))
+	if err := hamt64.WriteImage(ioutil.Discard, h); err == nil {
+		t.Fatalf("%s: WriteImage of an Int64Key did not fail", name)
+	}
+	h = hamt64.New(Functional, TableOption)
+	h, _ = h.Put(hamt64.StringKey("k"), 1)
+	if err := hamt64.WriteImage(ioutil.Discard, h); err == nil {
+		t.Fatalf("%s: WriteImage of an int value did not fail", name)
+	}
+
+	if _, err := hamt64.NewImage([]byte("short")); err == nil {
+		t.Fatalf("%s: NewImage of garbage did not fail", name)
+	}
+
+	var buf bytes.Buffer
+	hamt64.WriteImage(&buf, buildByteHamt64(100))
+	var data = buf.Bytes()
+	for _, off := range []int{20, len(data) / 2, len(data) - 20} {
+		var bad = append([]byte(nil), data...)
+		bad[off] ^= 0xff
+		var im, err = hamt64.NewImage(bad)
+		if err == nil {
+			err = im.Validate()
+		}
+		if err == nil {
+			t.Fatalf("%s: corruption at offset %d not detected", name, off)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package hamt64
+
+import (
+	"github.com/pkg/errors"
+)
+
+// WrapKey returns the KeyI for a value of a built-in type: a string is a
+// StringKey, a []byte a ByteSliceKey, an int or int64 an Int64Key, an int32
+// (or rune) an Int32Key, a uint or uint64 a Uint64Key, a uint32 a Uint32Key, a
+// float64 a Float64Key, a bool a BoolKey, and a uintptr a UintptrKey. A KeyI is
+// returned as is. Any other type is an error.
+func WrapKey(k interface{}) (KeyI, error) {
+	switch x := k.(type) {
+	case KeyI:
+		return x, nil
+	case string:
+		return StringKey(x), nil
+	case []byte:
+		return ByteSliceKey(x), nil
+	case int:
+		return Int64Key(x), nil
+	case int32:
+		return Int32Key(x), nil
+	case int64:
+		return Int64Key(x), nil
+	case uint:
+		return Uint64Key(x), nil
+	case uint32:
+		return Uint32Key(x), nil
+	case uint64:
+		return Uint64Key(x), nil
+	case float64:
+		return Float64Key(x), nil
+	case bool:
+		return BoolKey(x), nil
+	case uintptr:
+		return UintptrKey(x), nil
+	}
+	return nil, errors.Errorf("WrapKey: no KeyI for a key of type %T", k)
+}
+
+// UnwrapKey returns the built-in value of a key made by WrapKey; the string
+// of a StringKey, the []byte of a ByteSliceKey, the int64 of an Int64Key, and
+// so on. For any other key, such as a TupleKey or StructKey, it returns false.
+func UnwrapKey(k KeyI) (interface{}, bool) {
+	switch x := k.(type) {
+	case StringKey:
+		return string(x), true
+	case ByteSliceKey:
+		return []byte(x), true
+	case Int32Key:
+		return int32(x), true
+	case Int64Key:
+		return int64(x), true
+	case Uint32Key:
+		return uint32(x), true
+	case Uint64Key:
+		return uint64(x), true
+	case Float64Key:
+		return float64(x), true
+	case BoolKey:
+		return bool(x), true
+	case RuneKey:
+		return rune(x), true
+	case UintptrKey:
+		return uintptr(x), true
+	case *HashedKey:
+		return UnwrapKey(x.key)
+	}
+	return nil, false
+}
+
+// FromKeyVals returns a new Hamt, of mode functional and table option tblOpt,
+// holding the pairs of kvs. If a key appears more than once the last pair
+// wins.
+func FromKeyVals(functional bool, tblOpt int, kvs []KeyVal) Hamt {
+	return FromSeq(functional, tblOpt, func(yield func(KeyVal) bool) {
+		for _, kv := range kvs {
+			if !yield(kv) {
+				return
+			}
+		}
+	})
+}
+
+// FromSeq returns a new Hamt, of mode functional and table option tblOpt,
+// holding every pair seq yields; the yield func it is given always returns
+// true. If a key is yielded more than once the last pair wins.
+//
+// seq has the shape of a Go 1.23 iter.Seq[KeyVal], so any such iterator can
+// be passed, as can a closure over a channel, a database cursor, etc.
+func FromSeq(functional bool, tblOpt int, seq func(yield func(KeyVal) bool)) Hamt {
+	// Build it transient, without copying any table more than once, then
+	// freeze it if asked.
+	var h = New(false, tblOpt)
+	seq(func(kv KeyVal) bool {
+		h, _ = h.Put(kv.Key, kv.Val)
+		return true
+	})
+	if functional {
+		return h.ToFunctional()
+	}
+	return h
+}
+
+// ToMap returns a builtin map holding every pair of h, keyed by the values
+// UnwrapKey returns; except that the keys of ByteSliceKeys are converted to
+// strings, since a []byte can not be a map key.
+//
+// It fails if a key of h has no built-in value, or if two keys of h have the
+// same built-in value; such as StringKey("a") and ByteSliceKey("a").
+func ToMap(h Hamt) (map[interface{}]interface{}, error) {
+	var m = make(map[interface{}]interface{}, h.Nentries())
+	var err error
+	h.Range(func(k KeyI, v interface{}) bool {
+		var bk, ok = UnwrapKey(k)
+		if !ok {
+			err = errors.Errorf("ToMap: key %v, of type %T, has no built-in "+
+				"value", k, k)
+			return false
+		}
+		if bs, isBytes := bk.([]byte); isBytes {
+			bk = string(bs)
+		}
+		if _, exists := m[bk]; exists {
+			err = errors.Errorf("ToMap: more than one key of h maps to %#v", bk)
+			return false
+		}
+		m[bk] = v
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
@@ -0,0 +1,114 @@
+// +build go1.18
+
+package hamt64
+
+import (
+	"github.com/pkg/errors"
+)
+
+// MapKey is the set of builtin map key types FromMap and ToTypedMap convert
+// to and from KeyIs; the comparable types WrapKey has a KeyI for.
+type MapKey interface {
+	string | int | int32 | int64 | uint | uint32 | uint64 | float64 | bool |
+		uintptr
+}
+
+// FromMap returns a new Hamt, of mode functional and table option tblOpt,
+// holding the pairs of m. Every key is wrapped as WrapKey does; a string in a
+// StringKey, an int in an Int64Key, and so on.
+//
+//	var h = hamt64.FromMap(true, hamt64.HybridTables, map[string]int{"a": 1})
+func FromMap[K MapKey, V any](functional bool, tblOpt int, m map[K]V) Hamt {
+	return FromSeq(functional, tblOpt, func(yield func(KeyVal) bool) {
+		for k, v := range m {
+			// WrapKey never fails for a MapKey.
+			var key, _ = WrapKey(k)
+			if !yield(KeyVal{key, v}) {
+				return
+			}
+		}
+	})
+}
+
+// FromByteSliceMap returns a new Hamt, of mode functional and table option
+// tblOpt, holding the pairs of m with ByteSliceKey keys. A Go map can not be
+// keyed by a []byte, so the keys of m are their string conversions.
+func FromByteSliceMap[V any](functional bool, tblOpt int, m map[string]V) Hamt {
+	return FromSeq(functional, tblOpt, func(yield func(KeyVal) bool) {
+		for k, v := range m {
+			if !yield(KeyVal{ByteSliceKey(k), v}) {
+				return
+			}
+		}
+	})
+}
+
+// ToTypedMap returns a map[K]V holding every pair of h. The keys of h are
+// unwrapped with UnwrapKey, and must be of type K; except that a ByteSliceKey
+// becomes a string key, an Int64Key an int key, and a Uint64Key a uint key.
+// So FromMap and ToTypedMap, of the same K and V, round trip.
+//
+// It fails if a key or value of h is not of type K or V, or if two keys of h
+// have the same K value.
+func ToTypedMap[K MapKey, V any](h Hamt) (map[K]V, error) {
+	var m = make(map[K]V, h.Nentries())
+	var err error
+	h.Range(func(k KeyI, v interface{}) bool {
+		var bk, ok = UnwrapKey(k)
+		var key K
+		if ok {
+			key, ok = mapKeyOf[K](bk)
+		}
+		if !ok {
+			err = errors.Errorf("ToTypedMap: key %v, of type %T, is not a %T",
+				k, k, key)
+			return false
+		}
+
+		var val V
+		if v != nil || interface{}(val) != nil {
+			val, ok = v.(V)
+			if !ok {
+				err = errors.Errorf("ToTypedMap: value %v, of type %T, of key "+
+					"%v is not a %T", v, v, k, val)
+				return false
+			}
+		}
+
+		if _, exists := m[key]; exists {
+			err = errors.Errorf("ToTypedMap: more than one key of h maps to %#v",
+				key)
+			return false
+		}
+		m[key] = val
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// mapKeyOf converts bk, a value returned by UnwrapKey, to a K.
+func mapKeyOf[K MapKey](bk interface{}) (K, bool) {
+	if k, ok := bk.(K); ok {
+		return k, true
+	}
+
+	var zero K
+	switch interface{}(zero).(type) {
+	case string:
+		if bs, ok := bk.([]byte); ok {
+			return interface{}(string(bs)).(K), true
+		}
+	case int:
+		if i, ok := bk.(int64); ok && int64(int(i)) == i {
+			return interface{}(int(i)).(K), true
+		}
+	case uint:
+		if u, ok := bk.(uint64); ok && uint64(uint(u)) == u {
+			return interface{}(uint(u)).(K), true
+		}
+	}
+	return zero, false
+}
@@ -0,0 +1,105 @@
+// +build go1.18
+
+package hamt64_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/lleo/go-hamt/hamt64"
+)
+
+func TestHamt64FromMap(t *testing.T) {
+	var name = "TestHamt64FromMap"
+
+	var sm = make(map[string]int)
+	var im = make(map[int]string)
+	for i := 0; i < 1000; i++ {
+		sm[fmt.Sprintf("key%d", i)] = i
+		im[i] = fmt.Sprintf("val%d", i)
+	}
+
+	var sh = hamt64.FromMap(Functional, TableOption, sm)
+	if sh.Nentries() != uint(len(sm)) {
+		t.Fatalf("%s: Nentries(),%d != %d", name, sh.Nentries(), len(sm))
+	}
+	for k, v := range sm {
+		var val, found = sh.Get(hamt64.StringKey(k))
+		if !found || val != v {
+			t.Fatalf("%s: Get(%q) = %v, %t; expected %d, true",
+				name, k, val, found, v)
+		}
+	}
+	var sm2, err = hamt64.ToTypedMap[string, int](sh)
+	if err != nil {
+		t.Fatalf("%s: ToTypedMap[string, int] failed: %s", name, err)
+	}
+	checkMapsEqual(t, name, sm2, sm)
+
+	var ih = hamt64.FromMap(Functional, TableOption, im)
+	for k, v := range im {
+		var val, found = ih.Get(hamt64.Int64Key(k))
+		if !found || val != v {
+			t.Fatalf("%s: Get(%d) = %v, %t; expected %q, true",
+				name, k, val, found, v)
+		}
+	}
+	im2, err := hamt64.ToTypedMap[int, string](ih)
+	if err != nil {
+		t.Fatalf("%s: ToTypedMap[int, string] failed: %s", name, err)
+	}
+	checkMapsEqual(t, name, im2, im)
+
+	var bh = hamt64.FromByteSliceMap(Functional, TableOption, sm)
+	for k, v := range sm {
+		var val, found = bh.Get(hamt64.ByteSliceKey(k))
+		if !found || val != v {
+			t.Fatalf("%s: Get([]byte(%q)) = %v, %t; expected %d, true",
+				name, k, val, found, v)
+		}
+	}
+	bm, err := hamt64.ToTypedMap[string, int](bh)
+	if err != nil {
+		t.Fatalf("%s: ToTypedMap[string, int] of ByteSliceKeys failed: %s",
+			name, err)
+	}
+	checkMapsEqual(t, name, bm, sm)
+}
+
+func TestHamt64ToTypedMapErrors(t *testing.T) {
+	var name = "TestHamt64ToTypedMapErrors"
+
+	var h = hamt64.FromMap(Functional, TableOption, map[string]interface{}{
+		"a": 1, "b": "two", "c": nil})
+
+	if _, err := hamt64.ToTypedMap[string, int](h); err == nil {
+		t.Fatalf("%s: ToTypedMap[string, int] of a string value did not fail",
+			name)
+	}
+	if _, err := hamt64.ToTypedMap[int, interface{}](h); err == nil {
+		t.Fatalf("%s: ToTypedMap[int, interface{}] of string keys did not "+
+			"fail", name)
+	}
+	var m, err = hamt64.ToTypedMap[string, interface{}](h)
+	if err != nil || len(m) != 3 || m["c"] != nil {
+		t.Fatalf("%s: ToTypedMap[string, interface{}] = %v, %v; expected "+
+			"3 pairs", name, m, err)
+	}
+}
+
+func checkMapsEqual[K comparable, V comparable](
+	t *testing.T,
+	name string,
+	got, expected map[K]V,
+) {
+	if len(got) != len(expected) {
+		t.Fatalf("%s: map has %d pairs; expected %d",
+			name, len(got), len(expected))
+	}
+	for k, v := range expected {
+		if val, found := got[k]; !found || val != v {
+			t.Fatalf("%s: map[%v] = %v, %t; expected %v, true",
+				name, k, val, found, v)
+		}
+	}
+}
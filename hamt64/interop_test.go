@@ -0,0 +1,152 @@
+package hamt64_test
+
+import (
+	"testing"
+
+	"github.com/lleo/go-hamt/hamt64"
+)
+
+func TestHamt64WrapKey(t *testing.T) {
+	var name = "TestHamt64WrapKey"
+
+	var keys = []interface{}{"a", []byte("a"), int(-1), int32(-2), int64(-3),
+		uint(4), uint32(5), uint64(6), float64(7.5), true, uintptr(8)}
+	var expected = []hamt64.KeyI{hamt64.StringKey("a"),
+		hamt64.ByteSliceKey("a"), hamt64.Int64Key(-1), hamt64.Int32Key(-2),
+		hamt64.Int64Key(-3), hamt64.Uint64Key(4), hamt64.Uint32Key(5),
+		hamt64.Uint64Key(6), hamt64.Float64Key(7.5), hamt64.BoolKey(true),
+		hamt64.UintptrKey(8)}
+
+	for i, k := range keys {
+		var key, err = hamt64.WrapKey(k)
+		if err != nil {
+			t.Fatalf("%s: WrapKey(%#v) failed: %s", name, k, err)
+		}
+		if !key.Equals(expected[i]) {
+			t.Fatalf("%s: WrapKey(%#v) = %#v; expected %#v",
+				name, k, key, expected[i])
+		}
+		if _, ok := hamt64.UnwrapKey(key); !ok {
+			t.Fatalf("%s: UnwrapKey(%#v) failed", name, key)
+		}
+	}
+
+	var sk = hamt64.StringKey("b")
+	if key, err := hamt64.WrapKey(sk); err != nil || key != sk {
+		t.Fatalf("%s: WrapKey(%#v) = %#v, %v; expected it unchanged",
+			name, sk, key, err)
+	}
+	if _, err := hamt64.WrapKey(struct{}{}); err == nil {
+		t.Fatalf("%s: WrapKey(struct{}{}) did not fail", name)
+	}
+	if bk, ok := hamt64.UnwrapKey(hamt64.NewHashedKey(sk)); !ok || bk != "b" {
+		t.Fatalf("%s: UnwrapKey of a HashedKey = %#v, %t; expected \"b\"",
+			name, bk, ok)
+	}
+	if _, ok := hamt64.UnwrapKey(hamt64.TupleKey{sk}); ok {
+		t.Fatalf("%s: UnwrapKey of a TupleKey did not fail", name)
+	}
+}
+
+func TestHamt64FromKeyVals(t *testing.T) {
+	var name = "TestHamt64FromKeyVals"
+	var kvs = KVS64[:10000]
+
+	var h = hamt64.FromKeyVals(Functional, TableOption, kvs)
+	var cfg = h.Config()
+	if cfg.Functional != Functional || cfg.TableOption != TableOption {
+		t.Fatalf("%s: FromKeyVals made a %s", name, cfg)
+	}
+	var err = h.Validate()
+	if err != nil {
+		t.Fatalf("%s: Validate() failed: %s", name, err)
+	}
+	if h.Nentries() != uint(len(kvs)) {
+		t.Fatalf("%s: Nentries(),%d != %d", name, h.Nentries(), len(kvs))
+	}
+	for _, kv := range kvs {
+		var val, found = h.Get(kv.Key)
+		if !found || val != kv.Val {
+			t.Fatalf("%s: Get(%s) = %v, %t; expected %v, true",
+				name, kv.Key, val, found, kv.Val)
+		}
+	}
+
+	// The last of repeated keys wins.
+	h = hamt64.FromKeyVals(Functional, TableOption, []hamt64.KeyVal{
+		{hamt64.StringKey("a"), 1}, {hamt64.StringKey("a"), 2}})
+	if val, _ := h.Get(hamt64.StringKey("a")); h.Nentries() != 1 || val != 2 {
+		t.Fatalf("%s: repeated key has value %v of %d entries; expected 2 of 1",
+			name, val, h.Nentries())
+	}
+}
+
+func TestHamt64FromSeq(t *testing.T) {
+	var name = "TestHamt64FromSeq"
+	var kvs = KVS64[:1000]
+
+	var ch = make(chan hamt64.KeyVal)
+	go func() {
+		for _, kv := range kvs {
+			ch <- kv
+		}
+		close(ch)
+	}()
+
+	var h = hamt64.FromSeq(Functional, TableOption,
+		func(yield func(hamt64.KeyVal) bool) {
+			for kv := range ch {
+				if !yield(kv) {
+					return
+				}
+			}
+		})
+	if h.Nentries() != uint(len(kvs)) {
+		t.Fatalf("%s: Nentries(),%d != %d", name, h.Nentries(), len(kvs))
+	}
+	for _, kv := range kvs {
+		var val, found = h.Get(kv.Key)
+		if !found || val != kv.Val {
+			t.Fatalf("%s: Get(%s) = %v, %t; expected %v, true",
+				name, kv.Key, val, found, kv.Val)
+		}
+	}
+}
+
+func TestHamt64ToMap(t *testing.T) {
+	var name = "TestHamt64ToMap"
+
+	var h = hamt64.New(Functional, TableOption)
+	h, _ = h.Put(hamt64.StringKey("a"), 1)
+	h, _ = h.Put(hamt64.ByteSliceKey("b"), 2)
+	h, _ = h.Put(hamt64.Int64Key(3), 3)
+	h, _ = h.Put(hamt64.BoolKey(true), nil)
+
+	var m, err = hamt64.ToMap(h)
+	if err != nil {
+		t.Fatalf("%s: ToMap failed: %s", name, err)
+	}
+	var expected = map[interface{}]interface{}{
+		"a": 1, "b": 2, int64(3): 3, true: nil}
+	if len(m) != len(expected) {
+		t.Fatalf("%s: ToMap = %v; expected %v", name, m, expected)
+	}
+	for k, v := range expected {
+		if val, found := m[k]; !found || val != v {
+			t.Fatalf("%s: ToMap = %v; expected %v", name, m, expected)
+		}
+	}
+
+	// StringKey("b") and ByteSliceKey("b") are different keys of a Hamt but
+	// the same key of a map.
+	var dup, _ = h.Put(hamt64.StringKey("b"), 4)
+	if _, err = hamt64.ToMap(dup); err == nil {
+		t.Fatalf("%s: ToMap of StringKey(\"b\") and ByteSliceKey(\"b\") did "+
+			"not fail", name)
+	}
+
+	var tk, _ = h.Put(hamt64.TupleKey{hamt64.StringKey("c")}, 5)
+	if _, err = hamt64.ToMap(tk); err == nil {
+		t.Fatalf("%s: ToMap of a TupleKey did not fail", name)
+	}
+}
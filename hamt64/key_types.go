@@ -1,6 +1,10 @@
 package hamt64
 
-import "bytes"
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
 
 type ByteSliceKey []byte
 
@@ -16,10 +20,19 @@ func (bsk ByteSliceKey) Equals(K KeyI) bool {
 	return bytes.Equal(bsk, k)
 }
 
+// Less implements OrderedKeyI. ByteSliceKeys are ordered by bytes.Compare().
+func (bsk ByteSliceKey) Less(K KeyI) bool {
+	var k, ok = K.(ByteSliceKey)
+	if !ok {
+		return false
+	}
+	return bytes.Compare(bsk, k) < 0
+}
+
 type StringKey string
 
 func (sk StringKey) Hash() HashVal {
-	return CalcHash([]byte(sk))
+	return newHashState().addString(string(sk)).sum()
 }
 
 func (sk StringKey) Equals(K KeyI) bool {
@@ -30,15 +43,19 @@ func (sk StringKey) Equals(K KeyI) bool {
 	return sk == k
 }
 
+// Less implements OrderedKeyI. StringKeys are ordered lexically, byte-wise.
+func (sk StringKey) Less(K KeyI) bool {
+	var k, ok = K.(StringKey)
+	if !ok {
+		return false
+	}
+	return sk < k
+}
+
 type Int32Key int32
 
 func (ik Int32Key) Hash() HashVal {
-	return CalcHash([]byte{
-		byte(0xff000000 & uint32(ik) >> 3 * 8),
-		byte(0x00ff0000 & uint32(ik) >> 2 * 8),
-		byte(0x0000ff00 & uint32(ik) >> 1 * 8),
-		byte(0x000000ff & uint32(ik)),
-	})
+	return hashUint32(uint32(ik))
 }
 
 func (ik Int32Key) Equals(K KeyI) bool {
@@ -49,19 +66,19 @@ func (ik Int32Key) Equals(K KeyI) bool {
 	return ik == k
 }
 
+// Less implements OrderedKeyI. Int32Keys are ordered numerically.
+func (ik Int32Key) Less(K KeyI) bool {
+	var k, ok = K.(Int32Key)
+	if !ok {
+		return false
+	}
+	return ik < k
+}
+
 type Int64Key int64
 
 func (ik Int64Key) Hash() HashVal {
-	return CalcHash([]byte{
-		byte(0xff00000000000000 & uint64(ik) >> 7 * 8),
-		byte(0x00ff000000000000 & uint64(ik) >> 6 * 8),
-		byte(0x0000ff0000000000 & uint64(ik) >> 5 * 8),
-		byte(0x000000ff00000000 & uint64(ik) >> 4 * 8),
-		byte(0x00000000ff000000 & uint64(ik) >> 3 * 8),
-		byte(0x0000000000ff0000 & uint64(ik) >> 2 * 8),
-		byte(0x000000000000ff00 & uint64(ik) >> 1 * 8),
-		byte(0x00000000000000ff & uint64(ik)),
-	})
+	return hashUint64(uint64(ik))
 }
 
 func (ik Int64Key) Equals(K KeyI) bool {
@@ -72,15 +89,19 @@ func (ik Int64Key) Equals(K KeyI) bool {
 	return ik == k
 }
 
-type Uint32Key int32
+// Less implements OrderedKeyI. Int64Keys are ordered numerically.
+func (ik Int64Key) Less(K KeyI) bool {
+	var k, ok = K.(Int64Key)
+	if !ok {
+		return false
+	}
+	return ik < k
+}
+
+type Uint32Key uint32
 
 func (ik Uint32Key) Hash() HashVal {
-	return CalcHash([]byte{
-		byte(0xff000000 & uint32(ik) >> 3 * 8),
-		byte(0x00ff0000 & uint32(ik) >> 2 * 8),
-		byte(0x0000ff00 & uint32(ik) >> 1 * 8),
-		byte(0x000000ff & uint32(ik)),
-	})
+	return hashUint32(uint32(ik))
 }
 
 func (ik Uint32Key) Equals(K KeyI) bool {
@@ -91,19 +112,19 @@ func (ik Uint32Key) Equals(K KeyI) bool {
 	return ik == k
 }
 
-type Uint64Key int64
+// Less implements OrderedKeyI. Uint32Keys are ordered numerically.
+func (ik Uint32Key) Less(K KeyI) bool {
+	var k, ok = K.(Uint32Key)
+	if !ok {
+		return false
+	}
+	return ik < k
+}
+
+type Uint64Key uint64
 
 func (ik Uint64Key) Hash() HashVal {
-	return CalcHash([]byte{
-		byte(0xff00000000000000 & uint64(ik) >> 7 * 8),
-		byte(0x00ff000000000000 & uint64(ik) >> 6 * 8),
-		byte(0x0000ff0000000000 & uint64(ik) >> 5 * 8),
-		byte(0x000000ff00000000 & uint64(ik) >> 4 * 8),
-		byte(0x00000000ff000000 & uint64(ik) >> 3 * 8),
-		byte(0x0000000000ff0000 & uint64(ik) >> 2 * 8),
-		byte(0x000000000000ff00 & uint64(ik) >> 1 * 8),
-		byte(0x00000000000000ff & uint64(ik)),
-	})
+	return hashUint64(uint64(ik))
 }
 
 func (ik Uint64Key) Equals(K KeyI) bool {
@@ -113,3 +134,234 @@ func (ik Uint64Key) Equals(K KeyI) bool {
 	}
 	return ik == k
 }
+
+// Less implements OrderedKeyI. Uint64Keys are ordered numerically.
+func (ik Uint64Key) Less(K KeyI) bool {
+	var k, ok = K.(Uint64Key)
+	if !ok {
+		return false
+	}
+	return ik < k
+}
+
+// Float64Key is a float64 key. Its Equals method uses ==, so 0.0 and -0.0 are
+// the same key, and hash the same, while a NaN key never Equals anything,
+// itself included; a NaN key can be Put but never found.
+//
+// The varying bits of most float64 values are the sign, exponent, and high
+// mantissa bits, so Float64Key mixes its bits before hashing them.
+type Float64Key float64
+
+func (fk Float64Key) Hash() HashVal {
+	return hashUint64(floatBits(float64(fk)))
+}
+
+func (fk Float64Key) Equals(K KeyI) bool {
+	var k, ok = K.(Float64Key)
+	if !ok {
+		return false
+	}
+	return fk == k
+}
+
+// Less implements OrderedKeyI. Float64Keys are ordered numerically; NaN is
+// unordered.
+func (fk Float64Key) Less(K KeyI) bool {
+	var k, ok = K.(Float64Key)
+	if !ok {
+		return false
+	}
+	return fk < k
+}
+
+// BoolKey is a key type with only two values, false and true.
+type BoolKey bool
+
+func (bk BoolKey) Hash() HashVal {
+	if bk {
+		return hashUint32(1)
+	}
+	return hashUint32(0)
+}
+
+func (bk BoolKey) Equals(K KeyI) bool {
+	var k, ok = K.(BoolKey)
+	if !ok {
+		return false
+	}
+	return bk == k
+}
+
+// Less implements OrderedKeyI. false sorts before true.
+func (bk BoolKey) Less(K KeyI) bool {
+	var k, ok = K.(BoolKey)
+	if !ok {
+		return false
+	}
+	return !bool(bk) && bool(k)
+}
+
+// RuneKey is a unicode code point key. It hashes identically to Int32Key, but
+// a RuneKey never Equals an Int32Key.
+type RuneKey rune
+
+func (rk RuneKey) Hash() HashVal {
+	return hashUint32(uint32(rk))
+}
+
+func (rk RuneKey) Equals(K KeyI) bool {
+	var k, ok = K.(RuneKey)
+	if !ok {
+		return false
+	}
+	return rk == k
+}
+
+// Less implements OrderedKeyI. RuneKeys are ordered by code point.
+func (rk RuneKey) Less(K KeyI) bool {
+	var k, ok = K.(RuneKey)
+	if !ok {
+		return false
+	}
+	return rk < k
+}
+
+type UintptrKey uintptr
+
+func (uk UintptrKey) Hash() HashVal {
+	return hashUint64(uint64(uk))
+}
+
+func (uk UintptrKey) Equals(K KeyI) bool {
+	var k, ok = K.(UintptrKey)
+	if !ok {
+		return false
+	}
+	return uk == k
+}
+
+// Less implements OrderedKeyI. UintptrKeys are ordered numerically.
+func (uk UintptrKey) Less(K KeyI) bool {
+	var k, ok = K.(UintptrKey)
+	if !ok {
+		return false
+	}
+	return uk < k
+}
+
+// TupleKey is a composite key built from a list of component keys, for
+// example TupleKey{StringKey(tenant), StringKey(resource), Int64Key(version)}.
+// Its HashVal combines the HashVals of every component, and two TupleKeys are
+// Equal if they have the same number of components and every component is
+// Equal.
+type TupleKey []KeyI
+
+func (tk TupleKey) Hash() HashVal {
+	var h = newHashState()
+	for _, c := range tk {
+		h = h.addUint64(uint64(c.Hash()))
+	}
+	return h.sum()
+}
+
+func (tk TupleKey) Equals(K KeyI) bool {
+	var k, ok = K.(TupleKey)
+	if !ok || len(k) != len(tk) {
+		return false
+	}
+	for i := range tk {
+		if !tk[i].Equals(k[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Less implements OrderedKeyI. TupleKeys are ordered lexicographically,
+// comparing components with NaturalLess; a shorter TupleKey sorts before a
+// longer one it is a prefix of.
+func (tk TupleKey) Less(K KeyI) bool {
+	var k, ok = K.(TupleKey)
+	if !ok {
+		return false
+	}
+	for i := 0; i < len(tk) && i < len(k); i++ {
+		if NaturalLess(tk[i], k[i]) {
+			return true
+		}
+		if NaturalLess(k[i], tk[i]) {
+			return false
+		}
+	}
+	return len(tk) < len(k)
+}
+
+func (tk TupleKey) String() string {
+	var strs = make([]string, len(tk))
+	for i, c := range tk {
+		strs[i] = fmt.Sprintf("%v", c)
+	}
+	return "(" + strings.Join(strs, ", ") + ")"
+}
+
+// HashedKey wraps a key together with its precomputed HashVal. Build one with
+// NewHashedKey() when the same, expensive to hash, key is used for many Get,
+// Put, or Del calls, possibly on different Hamts; the key is hashed only once.
+//
+// The Hamt stores and compares the wrapped key, so a key Put as a HashedKey can
+// be found with the bare key and vice versa.
+type HashedKey struct {
+	key KeyI
+	hv  HashVal
+}
+
+// NewHashedKey hashes key and returns a HashedKey wrapping it. If key is
+// already a HashedKey it is returned as is.
+func NewHashedKey(key KeyI) *HashedKey {
+	if hk, ok := key.(*HashedKey); ok {
+		return hk
+	}
+	return &HashedKey{key, key.Hash()}
+}
+
+// Key returns the wrapped key.
+func (hk *HashedKey) Key() KeyI {
+	return hk.key
+}
+
+func (hk *HashedKey) Hash() HashVal {
+	return hk.hv
+}
+
+func (hk *HashedKey) Equals(K KeyI) bool {
+	if k, ok := K.(*HashedKey); ok {
+		K = k.key
+	}
+	return hk.key.Equals(K)
+}
+
+// Less implements OrderedKeyI by calling the Less method of the wrapped key,
+// with the key K wraps if K is a HashedKey too. It returns false if the wrapped
+// key does not implement OrderedKeyI.
+func (hk *HashedKey) Less(K KeyI) bool {
+	if k, ok := K.(*HashedKey); ok {
+		K = k.key
+	}
+	if ok, isOrdered := hk.key.(OrderedKeyI); isOrdered {
+		return ok.Less(K)
+	}
+	return false
+}
+
+func (hk *HashedKey) String() string {
+	return fmt.Sprintf("%v", hk.key)
+}
+
+// hashKey returns the key to store and compare, and its HashVal. A HashedKey
+// is replaced by the key it wraps and its precomputed HashVal.
+func hashKey(key KeyI) (KeyI, HashVal) {
+	if hk, ok := key.(*HashedKey); ok {
+		return hk.key, hk.hv
+	}
+	return key, key.Hash()
+}
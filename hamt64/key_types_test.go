@@ -0,0 +1,293 @@
+package hamt64_test
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"testing"
+
+	"github.com/lleo/go-hamt/hamt64"
+)
+
+// intKeyMakers construct every integer-like key type from a sequence number.
+var intKeyMakers = []struct {
+	name string
+	mk   func(i int) hamt64.KeyI
+}{
+	{"Int32Key", func(i int) hamt64.KeyI { return hamt64.Int32Key(i) }},
+	{"Int64Key", func(i int) hamt64.KeyI { return hamt64.Int64Key(i) }},
+	{"Uint32Key", func(i int) hamt64.KeyI { return hamt64.Uint32Key(i) }},
+	{"Uint64Key", func(i int) hamt64.KeyI { return hamt64.Uint64Key(i) }},
+	{"Float64Key", func(i int) hamt64.KeyI { return hamt64.Float64Key(i) }},
+	{"RuneKey", func(i int) hamt64.KeyI { return hamt64.RuneKey(i) }},
+	{"UintptrKey", func(i int) hamt64.KeyI { return hamt64.UintptrKey(i) }},
+}
+
+func TestHamt64IntKeyHash(t *testing.T) {
+	var b4 = make([]byte, 4)
+	var b8 = make([]byte, 8)
+
+	for _, v := range []int64{0, 1, -1, 255, 256, 1 << 31, -1 << 31, math.MaxInt64} {
+		binary.BigEndian.PutUint32(b4, uint32(v))
+		binary.BigEndian.PutUint64(b8, uint64(v))
+
+		if hamt64.Int32Key(v).Hash() != hamt64.CalcHash(b4) {
+			t.Fatalf("TestHamt64IntKeyHash: Int32Key(%d) hash mismatch", int32(v))
+		}
+		if hamt64.Uint32Key(v).Hash() != hamt64.CalcHash(b4) {
+			t.Fatalf("TestHamt64IntKeyHash: Uint32Key(%d) hash mismatch", uint32(v))
+		}
+		if hamt64.Int64Key(v).Hash() != hamt64.CalcHash(b8) {
+			t.Fatalf("TestHamt64IntKeyHash: Int64Key(%d) hash mismatch", v)
+		}
+		if hamt64.Uint64Key(v).Hash() != hamt64.CalcHash(b8) {
+			t.Fatalf("TestHamt64IntKeyHash: Uint64Key(%d) hash mismatch", uint64(v))
+		}
+	}
+
+	if hamt64.Float64Key(0).Hash() != hamt64.Float64Key(math.Copysign(0, -1)).Hash() {
+		t.Fatal("TestHamt64IntKeyHash: Float64Key 0.0 and -0.0 hash differently")
+	}
+	if hamt64.BoolKey(false).Hash() == hamt64.BoolKey(true).Hash() {
+		t.Fatal("TestHamt64IntKeyHash: BoolKey false and true hash the same")
+	}
+}
+
+// TestHamt64IntKeyDistribution buckets the hashes of sequential keys by their
+// root table index. Every bucket should get close to an equal share.
+func TestHamt64IntKeyDistribution(t *testing.T) {
+	const n = 32 * 1024
+	const expected = n / hamt64.IndexLimit
+	const slack = expected * 15 / 100
+
+	for _, km := range intKeyMakers {
+		var buckets [hamt64.IndexLimit]int
+		for i := 0; i < n; i++ {
+			buckets[km.mk(i).Hash().Index(0)]++
+		}
+		for idx, cnt := range buckets {
+			if cnt < expected-slack || cnt > expected+slack {
+				t.Fatalf("TestHamt64IntKeyDistribution: %s: root index %d "+
+					"got %d of %d hashes; expected %d±%d",
+					km.name, idx, cnt, n, expected, slack)
+			}
+		}
+	}
+}
+
+func TestHamt64IntKeyHashAllocs(t *testing.T) {
+	for _, km := range intKeyMakers {
+		var k = km.mk(12345)
+		var allocs = testing.AllocsPerRun(100, func() { k.Hash() })
+		if allocs != 0 {
+			t.Fatalf("TestHamt64IntKeyHashAllocs: %s.Hash() allocates %g times",
+				km.name, allocs)
+		}
+	}
+}
+
+func TestHamt64IntKeyPutGetDel(t *testing.T) {
+	var name = "TestHamt64IntKeyPutGetDel"
+
+	for _, km := range intKeyMakers {
+		var h = hamt64.New(Functional, TableOption)
+		for i := 0; i < 1000; i++ {
+			h, _ = h.Put(km.mk(i), i)
+		}
+		var err = h.Validate()
+		if err != nil {
+			t.Fatalf("%s: %s: Validate() failed: %s", name, km.name, err)
+		}
+		for i := 0; i < 1000; i++ {
+			var v, found = h.Get(km.mk(i))
+			if !found || v != i {
+				t.Fatalf("%s: %s: Get(%d) = %v, %v", name, km.name, i, v, found)
+			}
+		}
+		for i := 0; i < 1000; i++ {
+			var deleted bool
+			h, _, deleted = h.Del(km.mk(i))
+			if !deleted {
+				t.Fatalf("%s: %s: failed to Del(%d)", name, km.name, i)
+			}
+		}
+		if !h.IsEmpty() {
+			t.Fatalf("%s: %s: not empty after deleting every key", name, km.name)
+		}
+	}
+
+	var h = hamt64.New(Functional, TableOption)
+	h, _ = h.Put(hamt64.BoolKey(false), 0)
+	h, _ = h.Put(hamt64.BoolKey(true), 1)
+	h, _ = h.Put(hamt64.Float64Key(0), "zero")
+	var v, found = h.Get(hamt64.Float64Key(math.Copysign(0, -1)))
+	if !found || v != "zero" {
+		t.Fatalf("%s: Get(Float64Key(-0.0)) = %v, %v", name, v, found)
+	}
+	v, found = h.Get(hamt64.BoolKey(true))
+	if !found || v != 1 {
+		t.Fatalf("%s: Get(BoolKey(true)) = %v, %v", name, v, found)
+	}
+	if h.Nentries() != 3 {
+		t.Fatalf("%s: h.Nentries(),%d != 3", name, h.Nentries())
+	}
+}
+
+// TestHamt64CalcHash checks the inline FNV-1 hash against hash/fnv.
+func TestHamt64CalcHash(t *testing.T) {
+	const bits = hamt64.DepthLimit * hamt64.NumIndexBits
+	for _, kv := range KVS64[:1000] {
+		var s = string(kv.Key.(hamt64.StringKey))
+
+		var h = fnv.New64()
+		h.Write([]byte(s))
+		var sum = h.Sum64()
+		var expected = hamt64.HashVal((sum >> bits) ^ (sum & (1<<bits - 1)))
+
+		if hamt64.CalcHash([]byte(s)) != expected {
+			t.Fatalf("TestHamt64CalcHash: CalcHash(%q) = %s; expected %s",
+				s, hamt64.CalcHash([]byte(s)), expected)
+		}
+		if hamt64.StringKey(s).Hash() != expected ||
+			hamt64.ByteSliceKey(s).Hash() != expected {
+			t.Fatalf("TestHamt64CalcHash: StringKey(%q) or ByteSliceKey(%q) "+
+				"hash != CalcHash", s, s)
+		}
+	}
+
+	var bs = []byte("a moderately long key to hash")
+	var allocs = testing.AllocsPerRun(100, func() { hamt64.CalcHash(bs) })
+	if allocs != 0 {
+		t.Fatalf("TestHamt64CalcHash: CalcHash allocates %g times", allocs)
+	}
+}
+
+// TestHamt64GetAllocs checks that Get does not allocate for any of the
+// built-in key types.
+func TestHamt64GetAllocs(t *testing.T) {
+	var keyMakers = append([]struct {
+		name string
+		mk   func(i int) hamt64.KeyI
+	}{
+		{"StringKey", func(i int) hamt64.KeyI {
+			return hamt64.StringKey(fmt.Sprint("key", i))
+		}},
+		{"ByteSliceKey", func(i int) hamt64.KeyI {
+			return hamt64.ByteSliceKey(fmt.Sprint("key", i))
+		}},
+		{"BoolKey", func(i int) hamt64.KeyI { return hamt64.BoolKey(i%2 == 0) }},
+		{"TupleKey", func(i int) hamt64.KeyI {
+			return hamt64.TupleKey{hamt64.StringKey("t"), hamt64.Int64Key(i)}
+		}},
+		{"StructKey", func(i int) hamt64.KeyI {
+			return hamt64.NewStructKey(structKeyVal{Tenant: "t", Version: int64(i)})
+		}},
+	}, intKeyMakers...)
+
+	for _, km := range keyMakers {
+		var h = hamt64.New(Functional, TableOption)
+		for i := 0; i < 1000; i++ {
+			h, _ = h.Put(km.mk(i), i)
+		}
+
+		var found, missing = km.mk(1), km.mk(5000)
+		var allocs = testing.AllocsPerRun(100, func() {
+			h.Get(found)
+			h.Get(missing)
+		})
+		if allocs != 0 {
+			t.Fatalf("TestHamt64GetAllocs: %s: Get allocates %g times",
+				km.name, allocs)
+		}
+	}
+}
+
+// countingKey is a StringKey that counts how many times it is hashed.
+type countingKey struct {
+	s      string
+	hashes *int
+}
+
+func (k countingKey) Hash() hamt64.HashVal {
+	*k.hashes++
+	return hamt64.StringKey(k.s).Hash()
+}
+
+func (k countingKey) Equals(other hamt64.KeyI) bool {
+	var o, ok = other.(countingKey)
+	return ok && o.s == k.s
+}
+
+func TestHamt64LeafHashCached(t *testing.T) {
+	var name = "TestHamt64LeafHashCached"
+
+	var counts = make([]int, 2000)
+	var h = hamt64.New(Functional, TableOption)
+	for i := range counts {
+		h, _ = h.Put(countingKey{fmt.Sprint("key", i), &counts[i]}, i)
+	}
+	for i := 0; i < len(counts); i += 2 {
+		h, _, _ = h.Del(countingKey{fmt.Sprint("key", i), new(int)})
+	}
+	h.Stats()
+	h.LongString("")
+	h.ToTransient().DeepCopy()
+
+	for i := 1; i < len(counts); i += 2 {
+		if counts[i] != 1 {
+			t.Fatalf("%s: key%d hashed %d times; expected once", name, i, counts[i])
+		}
+	}
+}
+
+func TestHamt64HashedKey(t *testing.T) {
+	var name = "TestHamt64HashedKey"
+
+	var hashes int
+	var hk = hamt64.NewHashedKey(countingKey{"shared key", &hashes})
+	if hamt64.NewHashedKey(hk) != hk {
+		t.Fatalf("%s: NewHashedKey rewrapped a HashedKey", name)
+	}
+
+	var h1 = hamt64.New(Functional, TableOption)
+	var h2 = hamt64.New(!Functional, TableOption)
+	for _, kv := range KVS64[:1000] {
+		h1, _ = h1.Put(kv.Key, kv.Val)
+		h2, _ = h2.Put(kv.Key, kv.Val)
+	}
+
+	h1, _ = h1.Put(hk, 1)
+	h2, _ = h2.Put(hk, 2)
+	var v1, found1 = h1.Get(hk)
+	var v2, found2 = h2.Get(hk)
+	if !found1 || !found2 || v1 != 1 || v2 != 2 {
+		t.Fatalf("%s: Get(hk) = %v,%v and %v,%v", name, v1, found1, v2, found2)
+	}
+	h1, _, found1 = h1.Del(hk)
+	if !found1 {
+		t.Fatalf("%s: Del(hk) failed", name)
+	}
+	if hashes != 1 {
+		t.Fatalf("%s: key hashed %d times; expected once", name, hashes)
+	}
+
+	// The wrapped key is stored, so the bare key finds it.
+	var v, found = h2.Get(countingKey{"shared key", &hashes})
+	if !found || v != 2 {
+		t.Fatalf("%s: bare key Get = %v, %v", name, v, found)
+	}
+
+	// and a HashedKey finds a bare key.
+	var k = hamt64.StringKey("aaa")
+	v, found = h1.Get(hamt64.NewHashedKey(k))
+	var expected, _ = h1.Get(k)
+	if !found || v != expected {
+		t.Fatalf("%s: HashedKey Get = %v, %v", name, v, found)
+	}
+
+	var err = h2.Validate()
+	if err != nil {
+		t.Fatalf("%s: Validate() failed: %s", name, err)
+	}
+}
@@ -30,6 +30,15 @@ var KVS64 []hamt64.KeyVal
 var Functional bool
 var TableOption int
 
+// ValidateInterval is how often the big Put and Del suites call Validate().
+// Validate() walks the entire Hamt, so calling it after every one of numKvs
+// mutations would never finish; see the Validate tests for a bounded suite
+// that validates after every mutation.
+var ValidateInterval = 64 * 1024
+
+// ValidateNumKvs is the number of KeyVals used by the Validate tests.
+var ValidateNumKvs = 4 * 1024
+
 var Hamt64 hamt64.Hamt
 
 var Inc = stringutil.Lower.Inc
@@ -340,6 +349,20 @@ func buildHamt64(
 	return h, nil
 }
 
+// validateHamt64 is called after the i'th mutation of h. It only calls
+// h.Validate() when i is a multiple of ValidateInterval.
+func validateHamt64(t testing.TB, name string, h hamt64.Hamt, i int) {
+	if i%ValidateInterval != 0 {
+		return
+	}
+
+	var err = h.Validate()
+	if err != nil {
+		log.Printf("%s: Validate() failed after mutation #%d: %s", name, i, err)
+		t.Fatalf("%s: Validate() failed after mutation #%d: %s", name, i, err)
+	}
+}
+
 func RunTimes() string {
 	// Grab list of keys from RunTime map; MAJOR un-feature of Go!
 	var ks = make([]string, len(RunTime))
@@ -0,0 +1,167 @@
+package hamt64
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// EventKind identifies which kind of change an Event describes.
+type EventKind int
+
+const (
+	// Inserted means a new KeyVal pair was added.
+	Inserted EventKind = iota
+	// Replaced means the value of an existing key was replaced.
+	Replaced
+	// Deleted means a KeyVal pair was removed.
+	Deleted
+	// Upgraded means a sparseTable was converted to a fixedTable.
+	Upgraded
+	// Downgraded means a fixedTable was converted to a sparseTable.
+	Downgraded
+)
+
+// EventKindName maps each EventKind to its name.
+//
+//	hamt64.EventKindName[hamt64.Inserted] == "Inserted"
+var EventKindName = [...]string{
+	Inserted:   "Inserted",
+	Replaced:   "Replaced",
+	Deleted:    "Deleted",
+	Upgraded:   "Upgraded",
+	Downgraded: "Downgraded",
+}
+
+func (k EventKind) String() string {
+	return EventKindName[k]
+}
+
+// Event describes one change made to an observed Hamt.
+//
+// For Inserted, Replaced, and Deleted events Key is the key that changed,
+// OldVal is the value it had (nil for Inserted) and NewVal the value it has
+// now (nil for Deleted).
+//
+// For Upgraded and Downgraded events Key, OldVal, and NewVal are nil, and
+// HashPath and Depth identify the table that was converted.
+type Event struct {
+	Kind     EventKind
+	Key      KeyI
+	OldVal   interface{}
+	NewVal   interface{}
+	HashPath HashVal
+	Depth    uint
+}
+
+// Observer is called with the Events of every Put or Del that changes an
+// observed Hamt; or, inside a Batch, with every Event of the Batch at once.
+// The Events are in the order they happened. A Put causing a table upgrade
+// delivers the Upgraded Event before the Inserted Event.
+//
+// The events slice is only valid for the duration of the call. The Observer is
+// called synchronously, so it must not modify the Hamt it observes. Puts and
+// Dels of different versions of an observed HamtFunctional, in different
+// goroutines, call its Observer concurrently.
+type Observer func(events []Event)
+
+// observer holds the Observer of a Hamt and, while a Batch is open, the Events
+// it has not yet been given. A Hamt with no Observer has a nil *observer, so
+// the only cost of observation to unobserved Hamts is a nil check.
+//
+// Every version of a HamtFunctional derived by Put or Del shares its observer,
+// and those versions may be modified concurrently; so each Put or Del collects
+// its own Events, and the mutex is only taken while a Batch is open.
+type observer struct {
+	fn      Observer
+	batches int32 // read atomically; written with mu held
+
+	mu      sync.Mutex
+	pending []Event
+}
+
+// deliver gives the Events of one Put or Del to the Observer, or, if a Batch
+// is in progress, records them for delivery when it ends.
+func (o *observer) deliver(events []Event) {
+	if atomic.LoadInt32(&o.batches) > 0 {
+		o.mu.Lock()
+		if o.batches > 0 {
+			o.pending = append(o.pending, events...)
+			o.mu.Unlock()
+			return
+		}
+		o.mu.Unlock()
+	}
+	o.fn(events)
+}
+
+func putEvent(key KeyI, oldVal, newVal interface{}, added bool) Event {
+	if added {
+		return Event{Kind: Inserted, Key: key, NewVal: newVal}
+	}
+	return Event{Kind: Replaced, Key: key, OldVal: oldVal, NewVal: newVal}
+}
+
+func tableEvent(kind EventKind, t tableI, depth uint) Event {
+	return Event{Kind: kind, HashPath: t.Hash(), Depth: depth}
+}
+
+// Batch calls fn and delivers every Event caused by modifications of the Hamt
+// during fn to its Observer in one call, after fn returns. Batches may be
+// nested; the Events are delivered when the outermost Batch ends.
+//
+// For a HamtFunctional every version derived from it by Put or Del shares its
+// Observer, so modifications of those versions inside fn are batched too.
+//
+// If the Hamt has no Observer, Batch simply calls fn.
+func (h *hamtBase) Batch(fn func()) {
+	var o = h.obs
+	if o == nil {
+		fn()
+		return
+	}
+
+	o.mu.Lock()
+	atomic.AddInt32(&o.batches, 1)
+	o.mu.Unlock()
+
+	defer func() {
+		var events []Event
+		o.mu.Lock()
+		if atomic.AddInt32(&o.batches, -1) == 0 {
+			events, o.pending = o.pending, nil
+		}
+		o.mu.Unlock()
+		if len(events) > 0 {
+			o.fn(events)
+		}
+	}()
+
+	fn()
+}
+
+// SetObserver registers fn to be called with the Events of every subsequent
+// modification of the HamtTransient. A nil fn removes the Observer.
+//
+// A HamtFunctional made from h by ToFunctional keeps the Observer; one made by
+// DeepCopy does not.
+func (h *HamtTransient) SetObserver(fn Observer) {
+	h.obs = newObserver(fn)
+}
+
+// WithObserver returns a copy of h, sharing all its tables, which calls fn
+// with the Events of every modification of it and of every version derived
+// from it by Put or Del. h itself is not observed. A nil fn returns an
+// unobserved copy.
+func (h *HamtFunctional) WithObserver(fn Observer) *HamtFunctional {
+	var nh = new(HamtFunctional)
+	*nh = *h
+	nh.obs = newObserver(fn)
+	return nh
+}
+
+func newObserver(fn Observer) *observer {
+	if fn == nil {
+		return nil
+	}
+	return &observer{fn: fn}
+}
@@ -0,0 +1,200 @@
+package hamt64_test
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/lleo/go-hamt/hamt64"
+)
+
+// mirror is a secondary index maintained by an Observer.
+type mirror struct {
+	m       map[string]interface{}
+	calls   int
+	counts  map[hamt64.EventKind]int
+	badPrev int
+}
+
+func newMirror() *mirror {
+	return &mirror{
+		m:      make(map[string]interface{}),
+		counts: make(map[hamt64.EventKind]int),
+	}
+}
+
+func (mr *mirror) observe(events []hamt64.Event) {
+	mr.calls++
+	for _, e := range events {
+		mr.counts[e.Kind]++
+		switch e.Kind {
+		case hamt64.Inserted, hamt64.Replaced:
+			var s = fmt.Sprint(e.Key)
+			if mr.m[s] != e.OldVal {
+				mr.badPrev++
+			}
+			mr.m[s] = e.NewVal
+		case hamt64.Deleted:
+			var s = fmt.Sprint(e.Key)
+			if mr.m[s] != e.OldVal {
+				mr.badPrev++
+			}
+			delete(mr.m, s)
+		}
+	}
+}
+
+func (mr *mirror) check(t *testing.T, name string, h hamt64.Hamt) {
+	if mr.badPrev != 0 {
+		t.Fatalf("%s: %d Events had the wrong OldVal", name, mr.badPrev)
+	}
+	if uint(len(mr.m)) != h.Nentries() {
+		t.Fatalf("%s: mirror holds %d entries; h holds %d",
+			name, len(mr.m), h.Nentries())
+	}
+	h.Range(func(k hamt64.KeyI, v interface{}) bool {
+		if mr.m[fmt.Sprint(k)] != v {
+			t.Fatalf("%s: mirror[%s] = %v; h holds %v", name, k, mr.m[fmt.Sprint(k)], v)
+		}
+		return true
+	})
+}
+
+func TestHamt64Observer(t *testing.T) {
+	var name = "TestHamt64Observer"
+	var kvs = KVS64[:1000]
+
+	for _, functional := range []bool{true, false} {
+		var mr = newMirror()
+
+		var h hamt64.Hamt
+		if functional {
+			h = hamt64.NewFunctional(hamt64.HybridTables).WithObserver(mr.observe)
+		} else {
+			var ht = hamt64.NewTransient(hamt64.HybridTables)
+			ht.SetObserver(mr.observe)
+			h = ht
+		}
+
+		for _, kv := range kvs {
+			h, _ = h.Put(kv.Key, kv.Val)
+		}
+		for _, kv := range kvs[:100] {
+			h, _ = h.Put(kv.Key, -1)
+		}
+		for _, kv := range kvs[50:] {
+			h, _, _ = h.Del(kv.Key)
+		}
+		h, _, _ = h.Del(hamt64.StringKey("not a key"))
+
+		mr.check(t, name, h)
+		if mr.counts[hamt64.Inserted] != len(kvs) ||
+			mr.counts[hamt64.Replaced] != 100 ||
+			mr.counts[hamt64.Deleted] != len(kvs)-50 {
+			t.Fatalf("%s: functional=%t: unexpected Event counts %v",
+				name, functional, mr.counts)
+		}
+		if mr.counts[hamt64.Upgraded] == 0 || mr.counts[hamt64.Downgraded] == 0 {
+			t.Fatalf("%s: functional=%t: no table conversion Events %v",
+				name, functional, mr.counts)
+		}
+		if mr.calls != len(kvs)+100+len(kvs)-50 {
+			t.Fatalf("%s: functional=%t: Observer called %d times",
+				name, functional, mr.calls)
+		}
+	}
+}
+
+func TestHamt64ObserverBatch(t *testing.T) {
+	var name = "TestHamt64ObserverBatch"
+	var kvs = KVS64[:1000]
+
+	var mr = newMirror()
+	var h = hamt64.NewTransient(TableOption)
+	h.SetObserver(mr.observe)
+
+	h.Batch(func() {
+		for _, kv := range kvs {
+			h.Put(kv.Key, kv.Val)
+		}
+		h.Batch(func() {
+			for _, kv := range kvs[:100] {
+				h.Del(kv.Key)
+			}
+		})
+		if mr.calls != 0 {
+			t.Fatalf("%s: Observer called inside a Batch", name)
+		}
+	})
+	if mr.calls != 1 {
+		t.Fatalf("%s: Observer called %d times for one Batch", name, mr.calls)
+	}
+	mr.check(t, name, h)
+
+	// A functional Hamt batches the versions derived from it.
+	var mf = newMirror()
+	var hf = hamt64.NewFunctional(TableOption).WithObserver(mf.observe)
+	var cur hamt64.Hamt = hf
+	hf.Batch(func() {
+		for _, kv := range kvs {
+			cur, _ = cur.Put(kv.Key, kv.Val)
+		}
+	})
+	if mf.calls != 1 {
+		t.Fatalf("%s: functional Observer called %d times for one Batch",
+			name, mf.calls)
+	}
+	mf.check(t, name, cur)
+
+	// Removing the Observer stops the Events.
+	h.SetObserver(nil)
+	h.Put(hamt64.StringKey("unobserved"), 0)
+	if mr.calls != 1 {
+		t.Fatalf("%s: Observer called after removal", name)
+	}
+
+	// The Hamt WithObserver was called on is not observed.
+	var plain = hamt64.NewFunctional(TableOption)
+	plain.WithObserver(mr.observe)
+	plain.Put(hamt64.StringKey("unobserved"), 0)
+	if mr.calls != 1 {
+		t.Fatalf("%s: Observer called for the original Hamt", name)
+	}
+}
+
+// TestHamt64ObserverConcurrent checks that versions of an observed
+// HamtFunctional can still be modified concurrently; run it with -race.
+func TestHamt64ObserverConcurrent(t *testing.T) {
+	var name = "TestHamt64ObserverConcurrent"
+	var numGoroutines = 4
+	var kvs = KVS64[:1000]
+
+	var events int64
+	var h = hamt64.NewFunctional(TableOption).WithObserver(
+		func(evs []hamt64.Event) {
+			for _, e := range evs {
+				if e.Kind == hamt64.Inserted {
+					atomic.AddInt64(&events, 1)
+				}
+			}
+		})
+
+	var wg sync.WaitGroup
+	for g := 0; g < numGoroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var cur hamt64.Hamt = h
+			for _, kv := range kvs {
+				cur, _ = cur.Put(kv.Key, kv.Val)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if events != int64(numGoroutines*len(kvs)) {
+		t.Fatalf("%s: %d Inserted Events; expected %d",
+			name, events, numGoroutines*len(kvs))
+	}
+}
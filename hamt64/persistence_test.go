@@ -0,0 +1,119 @@
+package hamt64_test
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/lleo/go-hamt/hamt64"
+)
+
+// testVersion is a HamtFunctional version and the contents it must still
+// have when it is checked, by checkVersion.
+type testVersion struct {
+	h    hamt64.Hamt
+	kvs  map[int]int // key number => value
+	desc string      // identifies the version in failure messages
+}
+
+// kvs64Key returns the key of KVS64 with key number n.
+func kvs64Key(n int) hamt64.KeyI {
+	return KVS64[n].Key
+}
+
+// TestHamt64Persistence builds a branching history of HamtFunctional
+// versions; every batch of mutations starts from a randomly chosen older
+// version, not just the latest one. Since a HamtFunctional is never modified,
+// at the end every version must still hold exactly the contents it had when
+// it was made.
+func TestHamt64Persistence(t *testing.T) {
+	var numKeys = 500
+	var numBatches = 300
+	var maxBatch = 10
+
+	var opts = []int{hamt64.HybridTables, hamt64.FixedTables,
+		hamt64.SparseTables, hamt64.CompactTables}
+	for _, tblOpt := range opts {
+		for _, seed := range []int64{1, 2, 3} {
+			var name = fmt.Sprintf("TestHamt64Persistence:%s:seed=%d",
+				hamt64.TableOptionName[tblOpt], seed)
+			var r = rand.New(rand.NewSource(seed))
+
+			var versions = []testVersion{
+				{hamt64.New(true, tblOpt), map[int]int{}, "version 0"},
+			}
+
+			for b := 0; b < numBatches; b++ {
+				var parent = r.Intn(len(versions))
+				var h = versions[parent].h
+				var kvs = copyVersionMap(versions[parent].kvs)
+
+				var n = 1 + r.Intn(maxBatch)
+				for i := 0; i < n; i++ {
+					var ki = r.Intn(numKeys)
+					var k = KVS64[ki].Key
+					if r.Intn(3) == 0 {
+						h, _, _ = h.Del(k)
+						delete(kvs, ki)
+					} else {
+						var v = r.Int()
+						h, _ = h.Put(k, v)
+						kvs[ki] = v
+					}
+					versions = append(versions, testVersion{h,
+						copyVersionMap(kvs), fmt.Sprintf("version %d (of %d)",
+							len(versions), parent)})
+					parent = len(versions) - 1
+				}
+			}
+
+			for _, v := range versions {
+				checkVersion(t, name, v, kvs64Key, numKeys)
+			}
+		}
+	}
+}
+
+// checkVersion checks that v.h holds exactly the pairs of v.kvs, where
+// key(n) is the key of key number n, and numKeys is the number of keys.
+func checkVersion(
+	t *testing.T,
+	name string,
+	v testVersion,
+	key func(n int) hamt64.KeyI,
+	numKeys int,
+) {
+	var err = v.h.Validate()
+	if err != nil {
+		t.Fatalf("%s: %s: Validate() failed: %s", name, v.desc, err)
+	}
+	if v.h.Nentries() != uint(len(v.kvs)) {
+		t.Fatalf("%s: %s: Nentries(),%d != %d",
+			name, v.desc, v.h.Nentries(), len(v.kvs))
+	}
+	for n := 0; n < numKeys; n++ {
+		var expected, exists = v.kvs[n]
+		var val, found = v.h.Get(key(n))
+		if found != exists || (exists && val != expected) {
+			t.Fatalf("%s: %s: Get(%s) = %v, %t; expected %v, %t",
+				name, v.desc, key(n), val, found, expected, exists)
+		}
+	}
+	var nvisited int
+	v.h.Range(func(k hamt64.KeyI, val interface{}) bool {
+		nvisited++
+		return true
+	})
+	if nvisited != len(v.kvs) {
+		t.Fatalf("%s: %s: Range visited %d pairs; expected %d",
+			name, v.desc, nvisited, len(v.kvs))
+	}
+}
+
+func copyVersionMap(m map[int]int) map[int]int {
+	var nm = make(map[int]int, len(m))
+	for k, v := range m {
+		nm[k] = v
+	}
+	return nm
+}
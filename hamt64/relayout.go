@@ -0,0 +1,159 @@
+package hamt64
+
+// Relayout returns a new HamtFunctional with the same entries as h whose
+// tables follow the table option tblOpt; HybridTables, SparseTables, or
+// FixedTables. With CompactTables it returns a functional HamtCompact.
+//
+// Every table is rebuilt, but the leafs are shared with h, which is not
+// modified. Use it when a Hamt moves from one phase to another; for instance
+// FixedTables for a bulk load followed by SparseTables for a read-mostly
+// snapshot.
+func (h *HamtFunctional) Relayout(tblOpt int) Hamt {
+	if tblOpt == CompactTables {
+		return toCompact(h, true)
+	}
+	var nh = new(HamtFunctional)
+	nh.hamtBase = h.relayout(tblOpt)
+	return nh
+}
+
+// Relayout returns a new HamtTransient with the same entries as h whose tables
+// follow the table option tblOpt; HybridTables, SparseTables, or FixedTables.
+// With CompactTables it returns a transient HamtCompact.
+//
+// Every table is rebuilt, but the leafs are shared with h, which is not
+// modified.
+func (h *HamtTransient) Relayout(tblOpt int) Hamt {
+	if tblOpt == CompactTables {
+		return toCompact(h, false)
+	}
+	var nh = new(HamtTransient)
+	nh.hamtBase = h.relayout(tblOpt)
+	return nh
+}
+
+// Compact returns a new HamtFunctional with the same entries and table option
+// as h, in which every sparseTable has exactly the capacity it needs. Put
+// leaves room in sparseTables to grow; a long-lived snapshot does not need it.
+func (h *HamtFunctional) Compact() Hamt {
+	var nh = new(HamtFunctional)
+	nh.hamtBase = h.compact()
+	return nh
+}
+
+// Compact returns a new HamtTransient with the same entries and table option
+// as h, in which every sparseTable has exactly the capacity it needs.
+func (h *HamtTransient) Compact() Hamt {
+	var nh = new(HamtTransient)
+	nh.hamtBase = h.compact()
+	return nh
+}
+
+func (h *hamtBase) relayout(tblOpt int) hamtBase {
+	var nh = *h
+	nh.nograde, nh.startFixed = false, false
+	nh.init(tblOpt)
+
+	var ents = h.root.entries()
+	for i := range ents {
+		if t, isTable := ents[i].node.(tableI); isTable {
+			ents[i].node = nh.relayoutTable(t, 1)
+		}
+	}
+	nh.root = *upgradeToFixedTable(0, 0, ents)
+
+	return nh
+}
+
+// relayoutTable returns a copy of the table t, at depth, and every table below
+// it, with the table types h's table option calls for.
+func (h *hamtBase) relayoutTable(t tableI, depth uint) tableI {
+	var ents = t.entries()
+	for i := range ents {
+		if sub, isTable := ents[i].node.(tableI); isTable {
+			ents[i].node = h.relayoutTable(sub, depth+1)
+		}
+	}
+	return h.newTableWith(t.Hash(), depth, ents)
+}
+
+func (h *hamtBase) compact() hamtBase {
+	var nh = *h
+
+	var ents = h.root.entries()
+	for i := range ents {
+		if t, isTable := ents[i].node.(tableI); isTable {
+			ents[i].node = compactTable(t, 1)
+		}
+	}
+	nh.root = *upgradeToFixedTable(0, 0, ents)
+
+	return nh
+}
+
+// compactTable returns a copy of the table t, at depth, and every table below
+// it, of the same table types but without any spare capacity.
+func compactTable(t tableI, depth uint) tableI {
+	var ents = t.entries()
+	for i := range ents {
+		if sub, isTable := ents[i].node.(tableI); isTable {
+			ents[i].node = compactTable(sub, depth+1)
+		}
+	}
+
+	if _, isFixed := t.(*fixedTable); isFixed {
+		return upgradeToFixedTable(t.Hash(), depth, ents)
+	}
+
+	var st = new(sparseTable)
+	st.hashPath = t.Hash()
+	st.depth = depth
+	st.nodes = make([]nodeI, len(ents))
+	for i, ent := range ents {
+		st.nodeMap.Set(ent.idx)
+		st.nodes[i] = ent.node
+	}
+	return st
+}
+
+// Relayout returns a new Hamt with the same entries as h and the table option
+// tblOpt. With CompactTables it is the same as Compact, otherwise it returns a
+// HamtFunctional or HamtTransient, matching h, built with New.
+func (h *HamtCompact) Relayout(tblOpt int) Hamt {
+	if tblOpt == CompactTables {
+		return h.Compact()
+	}
+
+	var nh = New(false, tblOpt)
+	h.Range(func(k KeyI, v interface{}) bool {
+		nh, _ = nh.Put(k, v)
+		return true
+	})
+	if h.transient {
+		return nh
+	}
+	return nh.ToFunctional()
+}
+
+// Compact returns a new HamtCompact with the same entries and mode as h, in
+// which every node has exactly the capacity it needs.
+func (h *HamtCompact) Compact() Hamt {
+	return &HamtCompact{
+		root:      h.root.deepCopy(),
+		nentries:  h.nentries,
+		transient: h.transient,
+	}
+}
+
+// toCompact returns a HamtCompact holding the entries of h.
+func toCompact(h Hamt, functional bool) Hamt {
+	var nh Hamt = NewCompact(false)
+	h.Range(func(k KeyI, v interface{}) bool {
+		nh, _ = nh.Put(k, v)
+		return true
+	})
+	if functional {
+		return nh.ToFunctional()
+	}
+	return nh
+}
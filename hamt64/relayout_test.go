@@ -0,0 +1,132 @@
+package hamt64_test
+
+import (
+	"testing"
+
+	"github.com/lleo/go-hamt/hamt64"
+)
+
+func TestHamt64Relayout(t *testing.T) {
+	var name = "TestHamt64Relayout"
+	var kvs = KVS64[:20000]
+
+	var h, err = buildHamt64(name, kvs, Functional, TableOption)
+	if err != nil {
+		t.Fatalf("%s: buildHamt64 failed: %s", name, err)
+	}
+	var before = h.Stats()
+
+	var opts = []int{hamt64.FixedTables, hamt64.SparseTables,
+		hamt64.HybridTables, hamt64.CompactTables}
+	for _, opt := range opts {
+		var optName = hamt64.TableOptionName[opt]
+
+		var nh = h.Relayout(opt)
+		err = nh.Validate()
+		if err != nil {
+			t.Fatalf("%s: Relayout(%s).Validate() failed: %s",
+				name, optName, err)
+		}
+		if nh.Nentries() != uint(len(kvs)) {
+			t.Fatalf("%s: Relayout(%s).Nentries(),%d != %d",
+				name, optName, nh.Nentries(), len(kvs))
+		}
+		for _, kv := range kvs {
+			var val, found = nh.Get(kv.Key)
+			if !found || val != kv.Val {
+				t.Fatalf("%s: Relayout(%s).Get(%s) = %v, %t; expected %v",
+					name, optName, kv.Key, val, found, kv.Val)
+			}
+		}
+
+		var stats = nh.Stats()
+		switch opt {
+		case hamt64.FixedTables:
+			if stats.SparseTables != 0 {
+				t.Fatalf("%s: Relayout(%s) has %d SparseTables",
+					name, optName, stats.SparseTables)
+			}
+		case hamt64.SparseTables:
+			if stats.FixedTables != 1 { // the root
+				t.Fatalf("%s: Relayout(%s) has %d FixedTables",
+					name, optName, stats.FixedTables)
+			}
+		case hamt64.CompactTables:
+			if _, ok := nh.(*hamt64.HamtCompact); !ok {
+				t.Fatalf("%s: Relayout(%s) returned a %T", name, optName, nh)
+			}
+		}
+
+		// The relayed out Hamt keeps working under its new option, and is
+		// independent of h.
+		var deleted bool
+		nh, _, deleted = nh.Del(kvs[0].Key)
+		if !deleted {
+			t.Fatalf("%s: Relayout(%s).Del(%s) failed",
+				name, optName, kvs[0].Key)
+		}
+		if _, found := h.Get(kvs[0].Key); !found {
+			t.Fatalf("%s: deleting from Relayout(%s) modified h", name, optName)
+		}
+		if h.Stats().Tables != before.Tables {
+			t.Fatalf("%s: Relayout(%s) modified h", name, optName)
+		}
+
+		// And it can be relayed out again.
+		var back = nh.Relayout(TableOption)
+		if back.Nentries() != uint(len(kvs)-1) {
+			t.Fatalf("%s: Relayout(%s).Relayout(%s).Nentries(),%d != %d",
+				name, optName, hamt64.TableOptionName[TableOption],
+				back.Nentries(), len(kvs)-1)
+		}
+		err = back.Validate()
+		if err != nil {
+			t.Fatalf("%s: Relayout(%s).Relayout(%s).Validate() failed: %s",
+				name, optName, hamt64.TableOptionName[TableOption], err)
+		}
+	}
+}
+
+func TestHamt64CompactCapacity(t *testing.T) {
+	var name = "TestHamt64CompactCapacity"
+	var kvs = KVS64[:20000]
+
+	var h, err = buildHamt64(name, kvs, Functional, TableOption)
+	if err != nil {
+		t.Fatalf("%s: buildHamt64 failed: %s", name, err)
+	}
+
+	var nh = h.Compact()
+	err = nh.Validate()
+	if err != nil {
+		t.Fatalf("%s: Compact().Validate() failed: %s", name, err)
+	}
+	var hs, ns = h.Stats(), nh.Stats()
+	if hs.FixedTables != ns.FixedTables || hs.SparseTables != ns.SparseTables ||
+		hs.KeyVals != ns.KeyVals {
+		t.Fatalf("%s: Compact() changed the tables; %+v != %+v", name, hs, ns)
+	}
+
+	for _, kv := range kvs {
+		var val, found = nh.Get(kv.Key)
+		if !found || val != kv.Val {
+			t.Fatalf("%s: Compact().Get(%s) = %v, %t; expected %v",
+				name, kv.Key, val, found, kv.Val)
+		}
+	}
+
+	// A compacted Hamt grows again as usual.
+	for i := 0; i < 1000; i++ {
+		nh, _, _ = nh.Del(kvs[i].Key)
+	}
+	for i := 0; i < 1000; i++ {
+		nh, _ = nh.Put(kvs[i].Key, kvs[i].Val)
+	}
+	err = nh.Validate()
+	if err != nil {
+		t.Fatalf("%s: Validate() failed after modifying Compact(): %s", name, err)
+	}
+	if nh.Nentries() != uint(len(kvs)) {
+		t.Fatalf("%s: nh.Nentries(),%d != %d", name, nh.Nentries(), len(kvs))
+	}
+}
@@ -0,0 +1,260 @@
+package hamt64
+
+import (
+	"github.com/pkg/errors"
+)
+
+// SubTrie returns a new HamtFunctional containing exactly the KeyVal pairs of
+// h whose HashVal starts with the first depth indexes of hashPath; that is
+// every pair where key.Hash().HashPathString(depth) ==
+// hashPath.HashPathString(depth). With depth 0 that is every pair in h.
+//
+// No key is rehashed. The tables and leafs below the prefix are shared with h
+// when h is a HamtFunctional, and deep copied from h when it is a
+// HamtTransient, so later modifications of h never affect the SubTrie.
+func SubTrie(h Hamt, hashPath HashVal, depth uint) (*HamtFunctional, error) {
+	var hb, err = baseOf(h)
+	if err != nil {
+		return nil, errors.Wrap(err, "SubTrie")
+	}
+	if depth > DepthLimit {
+		return nil, errors.Errorf("SubTrie: depth,%d > DepthLimit,%d",
+			depth, DepthLimit)
+	}
+	var _, functional = h.(*HamtFunctional)
+
+	var nh = newLike(hb)
+
+	if depth == 0 {
+		if functional {
+			nh.root = hb.root
+		} else {
+			nh.root = *hb.root.deepCopy().(*fixedTable)
+		}
+		nh.nentries = hb.nentries
+		return nh, nil
+	}
+
+	hashPath &= hashPathMask(depth)
+
+	// Descend to the node holding every entry with the hashPath prefix. That
+	// is either the table at depth, or a leaf stored above depth.
+	var node nodeI = &hb.root
+	for d := uint(0); d < depth; d++ {
+		var t, isTable = node.(tableI)
+		if !isTable {
+			break
+		}
+		node = t.get(hashPath.Index(d))
+		if node == nil {
+			return nh, nil
+		}
+	}
+
+	switch x := node.(type) {
+	case leafI:
+		if x.Hash()&hashPathMask(depth) != hashPath {
+			return nh, nil
+		}
+		nh.root.insert(x.Hash().Index(0), x)
+	case tableI:
+		if !functional {
+			x = x.deepCopy()
+		}
+		nh.graft(hashPath, depth, x)
+	}
+
+	nh.nentries = countKeyVals(node)
+
+	return nh, nil
+}
+
+// SubTriePath is SubTrie with the hash path prefix given in HashPathString
+// form, for example "/03/17".
+func SubTriePath(h Hamt, path string) (*HamtFunctional, error) {
+	var hashPath, depth, err = ParseHashPath(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "SubTriePath")
+	}
+	return SubTrie(h, hashPath, depth)
+}
+
+// Split partitions h into n disjoint HamtFunctional shards along root table
+// index boundaries; shard i holds the KeyVal pairs whose root index is in
+// [i*IndexLimit/n, (i+1)*IndexLimit/n). n must be between 1 and IndexLimit.
+//
+// As with SubTrie no key is rehashed and the tables below the root are shared
+// with h, or deep copied if h is a HamtTransient. The shards of a Split may be
+// put back together with Join.
+func Split(h Hamt, n int) ([]*HamtFunctional, error) {
+	var hb, err = baseOf(h)
+	if err != nil {
+		return nil, errors.Wrap(err, "Split")
+	}
+	if n < 1 || n > IndexLimit {
+		return nil, errors.Errorf("Split: n,%d not in [1, IndexLimit,%d]",
+			n, IndexLimit)
+	}
+	var _, functional = h.(*HamtFunctional)
+
+	var shards = make([]*HamtFunctional, n)
+	for i := range shards {
+		var nh = newLike(hb)
+		var lo, hi = uint(i * IndexLimit / n), uint((i + 1) * IndexLimit / n)
+		for idx := lo; idx < hi; idx++ {
+			var node = hb.root.get(idx)
+			if node == nil {
+				continue
+			}
+			if t, isTable := node.(tableI); isTable && !functional {
+				node = t.deepCopy()
+			}
+			nh.root.insert(idx, node)
+			nh.nentries += countKeyVals(node)
+		}
+		shards[i] = nh
+	}
+
+	return shards, nil
+}
+
+// Join builds a new HamtFunctional holding every KeyVal pair of the given
+// shards. It is the inverse of Split; for example each of several goroutines
+// may build a shard from the keys with its own range of root indexes, and
+// Join combines them.
+//
+// The shards' tables are grafted into the new Hamt without touching their
+// leafs, so no key is rehashed. Where two shards hold tables in the same slot
+// those tables are merged. The shards must be disjoint: it is an error for
+// two shards to hold a leaf in the same slot, or a leaf and a table. All the
+// shards must use the same table option.
+//
+// The tables of HamtFunctional shards are shared with the result; the tables
+// of HamtTransient shards are deep copied.
+func Join(shards ...Hamt) (*HamtFunctional, error) {
+	if len(shards) == 0 {
+		return nil, errors.New("Join: no shards")
+	}
+
+	var nh *HamtFunctional
+	for i, shard := range shards {
+		var hb, err = baseOf(shard)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Join: shard %d", i)
+		}
+		if nh == nil {
+			nh = newLike(hb)
+		} else if hb.nograde != nh.nograde || hb.startFixed != nh.startFixed {
+			return nil, errors.Errorf(
+				"Join: shard %d has a different table option than shard 0", i)
+		}
+		var _, functional = shard.(*HamtFunctional)
+
+		for _, ent := range hb.root.entries() {
+			var node = ent.node
+			if t, isTable := node.(tableI); isTable && !functional {
+				node = t.deepCopy()
+			}
+
+			var cur = nh.root.get(ent.idx)
+			node, err = nh.merge(cur, node, HashVal(0).buildHashPath(ent.idx, 0), 1)
+			if err != nil {
+				return nil, errors.Wrapf(err, "Join: shard %d", i)
+			}
+
+			if cur == nil {
+				nh.root.insert(ent.idx, node)
+			} else {
+				nh.root.replace(ent.idx, node)
+			}
+		}
+
+		nh.nentries += hb.nentries
+	}
+
+	return nh, nil
+}
+
+// merge combines the nodes a and b, which both occupy the slot of a table at
+// depth-1 identified by the hashPath of the given depth. Either may be nil.
+// Two tables are merged into a new table; any other combination of non-nil
+// nodes means the shards are not disjoint.
+func (h *hamtBase) merge(a, b nodeI, hashPath HashVal, depth uint) (nodeI, error) {
+	if a == nil {
+		return b, nil
+	}
+	if b == nil {
+		return a, nil
+	}
+
+	var ta, aIsTable = a.(tableI)
+	var tb, bIsTable = b.(tableI)
+	if !aIsTable || !bIsTable {
+		return nil, errors.Errorf("shards overlap at %s",
+			hashPath.HashPathString(depth))
+	}
+
+	var ents []tableEntry
+	for idx := uint(0); idx < IndexLimit; idx++ {
+		var n, err = h.merge(ta.get(idx), tb.get(idx),
+			hashPath.buildHashPath(idx, depth), depth+1)
+		if err != nil {
+			return nil, err
+		}
+		if n != nil {
+			ents = append(ents, tableEntry{idx, n})
+		}
+	}
+
+	return h.newTableWith(hashPath, depth, ents), nil
+}
+
+// newLike returns a new empty HamtFunctional with the same table option as
+// hb.
+func newLike(hb *hamtBase) *HamtFunctional {
+	var nh = new(HamtFunctional)
+	nh.nograde = hb.nograde
+	nh.startFixed = hb.startFixed
+	return nh
+}
+
+// newTableWith returns a new table at depth with the given hashPath holding
+// the entries ents. The table type follows the table option of h the same
+// way Put does.
+func (h *hamtBase) newTableWith(
+	hashPath HashVal,
+	depth uint,
+	ents []tableEntry,
+) tableI {
+	if h.startFixed || (!h.nograde && uint(len(ents)) >= UpgradeThreshold) {
+		return upgradeToFixedTable(hashPath, depth, ents)
+	}
+	return downgradeToSparseTable(hashPath, depth, ents)
+}
+
+// graft places the table t, whose depth is depth and whose hash path is
+// hashPath, into h. Single entry tables are created for every depth between
+// the root and t. h must not already hold an entry with the hashPath prefix.
+func (h *hamtBase) graft(hashPath HashVal, depth uint, t tableI) {
+	var child nodeI = t
+	for d := depth - 1; d > 0; d-- {
+		child = h.newTableWith(hashPath&hashPathMask(d), d,
+			[]tableEntry{{hashPath.Index(d), child}})
+	}
+	h.root.insert(hashPath.Index(0), child)
+}
+
+// countKeyVals returns the number of KeyVal pairs stored in and below n.
+func countKeyVals(n nodeI) uint {
+	var nkvs uint
+	n.visit(func(n nodeI) bool {
+		switch x := n.(type) {
+		case *flatLeaf:
+			nkvs++
+		case *collisionLeaf:
+			nkvs += uint(len(x.kvs))
+		}
+		return true
+	})
+	return nkvs
+}
@@ -0,0 +1,240 @@
+package hamt64_test
+
+import (
+	"testing"
+
+	"github.com/lleo/go-hamt/hamt64"
+)
+
+// hasPrefix reports whether the HashVal of k starts with the first depth
+// indexes of hashPath.
+func hasPrefix(k hamt64.KeyI, hashPath hamt64.HashVal, depth uint) bool {
+	var hv = k.Hash()
+	return hv.HashPathString(depth) == hashPath.HashPathString(depth)
+}
+
+func TestHamt64SubTrie(t *testing.T) {
+	var name = "TestHamt64SubTrie"
+	var kvs = KVS64[:20000]
+
+	var h, err = buildHamt64(name, kvs, Functional, TableOption)
+	if err != nil {
+		t.Fatalf("%s: buildHamt64 failed: %s", name, err)
+	}
+
+	var paths = []string{"/", "/03", "/03/17", "/31/00/05",
+		kvs[0].Key.Hash().String()}
+	for _, path := range paths {
+		var hashPath, depth, err = hamt64.ParseHashPath(path)
+		if err != nil {
+			t.Fatalf("%s: ParseHashPath(%q) failed: %s", name, path, err)
+		}
+
+		var sub *hamt64.HamtFunctional
+		sub, err = hamt64.SubTriePath(h, path)
+		if err != nil {
+			t.Fatalf("%s: SubTriePath(%q) failed: %s", name, path, err)
+		}
+		err = sub.Validate()
+		if err != nil {
+			t.Fatalf("%s: SubTriePath(%q).Validate() failed: %s", name, path, err)
+		}
+
+		var expected uint
+		for _, kv := range kvs {
+			var val, found = sub.Get(kv.Key)
+			if hasPrefix(kv.Key, hashPath, depth) {
+				expected++
+				if !found || val != kv.Val {
+					t.Fatalf("%s: SubTriePath(%q) lacks %s", name, path, kv.Key)
+				}
+			} else if found {
+				t.Fatalf("%s: SubTriePath(%q) holds %s", name, path, kv.Key)
+			}
+		}
+		if sub.Nentries() != expected {
+			t.Fatalf("%s: SubTriePath(%q).Nentries(),%d != %d",
+				name, path, sub.Nentries(), expected)
+		}
+	}
+
+	// The SubTrie is independent of later modifications of h.
+	var sub, _ = hamt64.SubTriePath(h, "/")
+	for _, kv := range kvs[:100] {
+		h, _, _ = h.Del(kv.Key)
+	}
+	if sub.Nentries() != uint(len(kvs)) {
+		t.Fatalf("%s: SubTrie modified by h.Del()", name)
+	}
+	if _, found := sub.Get(kvs[0].Key); !found {
+		t.Fatalf("%s: SubTrie lost %s after h.Del()", name, kvs[0].Key)
+	}
+
+	for _, path := range []string{"03", "/03/", "/32", "/1/2/3/4/5/6/7/8/9/10/11/12/13"} {
+		if _, err = hamt64.SubTriePath(h, path); err == nil {
+			t.Fatalf("%s: SubTriePath(%q) did not fail", name, path)
+		}
+	}
+}
+
+func TestHamt64Split(t *testing.T) {
+	var name = "TestHamt64Split"
+	var kvs = KVS64[:20000]
+
+	var h, err = buildHamt64(name, kvs, Functional, TableOption)
+	if err != nil {
+		t.Fatalf("%s: buildHamt64 failed: %s", name, err)
+	}
+
+	for _, n := range []int{1, 3, 8, hamt64.IndexLimit} {
+		var shards []*hamt64.HamtFunctional
+		shards, err = hamt64.Split(h, n)
+		if err != nil {
+			t.Fatalf("%s: Split(h, %d) failed: %s", name, n, err)
+		}
+		if len(shards) != n {
+			t.Fatalf("%s: Split(h, %d) returned %d shards", name, n, len(shards))
+		}
+
+		var total uint
+		for i, shard := range shards {
+			err = shard.Validate()
+			if err != nil {
+				t.Fatalf("%s: Split(h, %d)[%d].Validate() failed: %s",
+					name, n, i, err)
+			}
+			total += shard.Nentries()
+		}
+		if total != h.Nentries() {
+			t.Fatalf("%s: Split(h, %d) shards hold %d entries; h holds %d",
+				name, n, total, h.Nentries())
+		}
+
+		for _, kv := range kvs {
+			var idx = int(kv.Key.Hash().Index(0))
+			var i = 0
+			for idx >= (i+1)*hamt64.IndexLimit/n {
+				i++
+			}
+			if _, found := shards[i].Get(kv.Key); !found {
+				t.Fatalf("%s: Split(h, %d)[%d] lacks %s", name, n, i, kv.Key)
+			}
+		}
+	}
+
+	for _, n := range []int{0, -1, hamt64.IndexLimit + 1} {
+		if _, err = hamt64.Split(h, n); err == nil {
+			t.Fatalf("%s: Split(h, %d) did not fail", name, n)
+		}
+	}
+}
+
+func TestHamt64Join(t *testing.T) {
+	var name = "TestHamt64Join"
+	var kvs = KVS64[:20000]
+
+	var h, err = buildHamt64(name, kvs, Functional, TableOption)
+	if err != nil {
+		t.Fatalf("%s: buildHamt64 failed: %s", name, err)
+	}
+
+	var checkJoined = func(desc string, j *hamt64.HamtFunctional,
+		holds func(k hamt64.KeyI) bool) {
+		var err = j.Validate()
+		if err != nil {
+			t.Fatalf("%s: %s: Validate() failed: %s", name, desc, err)
+		}
+		var expected uint
+		for _, kv := range kvs {
+			var val, found = j.Get(kv.Key)
+			if holds(kv.Key) {
+				expected++
+				if !found || val != kv.Val {
+					t.Fatalf("%s: %s: lacks %s", name, desc, kv.Key)
+				}
+			} else if found {
+				t.Fatalf("%s: %s: holds %s", name, desc, kv.Key)
+			}
+		}
+		if j.Nentries() != expected {
+			t.Fatalf("%s: %s: Nentries(),%d != %d",
+				name, desc, j.Nentries(), expected)
+		}
+	}
+	var all = func(hamt64.KeyI) bool { return true }
+
+	for _, n := range []int{1, 3, 8, hamt64.IndexLimit} {
+		var shards, _ = hamt64.Split(h, n)
+		var hs = make([]hamt64.Hamt, len(shards))
+		for i, shard := range shards {
+			if i%2 == 0 {
+				hs[i] = shard
+			} else {
+				hs[i] = shard.ToTransient()
+			}
+		}
+		var j *hamt64.HamtFunctional
+		j, err = hamt64.Join(hs...)
+		if err != nil {
+			t.Fatalf("%s: Join(Split(h, %d)...) failed: %s", name, n, err)
+		}
+		checkJoined("Join(Split(h, n)...)", j, all)
+	}
+
+	// SubTries sharing a prefix have their tables merged.
+	var paths = []string{"/03/01", "/03/02", "/03/17/04", "/05"}
+	var subs = make([]hamt64.Hamt, len(paths))
+	for i, path := range paths {
+		subs[i], err = hamt64.SubTriePath(h, path)
+		if err != nil {
+			t.Fatalf("%s: SubTriePath(%q) failed: %s", name, path, err)
+		}
+	}
+	var j *hamt64.HamtFunctional
+	j, err = hamt64.Join(subs...)
+	if err != nil {
+		t.Fatalf("%s: Join(SubTries...) failed: %s", name, err)
+	}
+	checkJoined("Join(SubTries...)", j, func(k hamt64.KeyI) bool {
+		for _, path := range paths {
+			var hashPath, depth, _ = hamt64.ParseHashPath(path)
+			if hasPrefix(k, hashPath, depth) {
+				return true
+			}
+		}
+		return false
+	})
+
+	// Overlapping shards are rejected.
+	var overlapping = [][]string{
+		{"/03", "/03/02"},
+		{"/", "/05"},
+		{"/07/01", "/07/01"},
+	}
+	for _, pair := range overlapping {
+		var a, _ = hamt64.SubTriePath(h, pair[0])
+		var b, _ = hamt64.SubTriePath(h, pair[1])
+		if _, err = hamt64.Join(a, b); err == nil {
+			t.Fatalf("%s: Join(%q, %q) did not fail", name, pair[0], pair[1])
+		}
+	}
+
+	var single = hamt64.New(Functional, TableOption)
+	single, _ = single.Put(kvs[0].Key, kvs[0].Val)
+	if _, err = hamt64.Join(h, single); err == nil {
+		t.Fatalf("%s: Join of shards holding the same key did not fail", name)
+	}
+
+	var other = hamt64.FixedTables
+	if TableOption == hamt64.FixedTables {
+		other = hamt64.SparseTables
+	}
+	if _, err = hamt64.Join(h, hamt64.New(Functional, other)); err == nil {
+		t.Fatalf("%s: Join of shards with different table options did not fail",
+			name)
+	}
+
+	if _, err = hamt64.Join(); err == nil {
+		t.Fatalf("%s: Join() did not fail", name)
+	}
+}
@@ -0,0 +1,144 @@
+package hamt64
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// OrderedKeyI is implemented by key types with a natural order. All the key
+// types provided by this library implement it except StructKey, which
+// NaturalLess orders by its fmt "%v" representation. A *HashedKey implements
+// it by delegating to the key it wraps.
+//
+// Less reports whether the key sorts before the argument. It returns false if
+// the argument is not of the same type.
+type OrderedKeyI interface {
+	KeyI
+	Less(KeyI) bool
+}
+
+// NaturalLess is a comparator for RangeSorted and NewSortedIndex that orders
+// keys of the same type by their Less method. Keys of different types, or
+// keys that do not implement OrderedKeyI, are ordered by type name; keys of
+// the same type that do not implement OrderedKeyI are ordered by their
+// fmt "%v" representation.
+func NaturalLess(a, b KeyI) bool {
+	// Comparing the reflect.Types does not allocate; the type names are only
+	// built when they are needed.
+	if reflect.TypeOf(a) != reflect.TypeOf(b) {
+		return fmt.Sprintf("%T", a) < fmt.Sprintf("%T", b)
+	}
+	if oa, ok := a.(OrderedKeyI); ok {
+		return oa.Less(b)
+	}
+	return fmt.Sprintf("%v", a) < fmt.Sprintf("%v", b)
+}
+
+// sortedKeyVals returns every KeyVal pair in the Hamt sorted by less.
+func (h *hamtBase) sortedKeyVals(less func(a, b KeyI) bool) []KeyVal {
+	var kvs = make([]KeyVal, 0, h.nentries)
+	h.Range(func(k KeyI, v interface{}) bool {
+		kvs = append(kvs, KeyVal{k, v})
+		return true
+	})
+	sort.Slice(kvs, func(i, j int) bool {
+		return less(kvs[i].Key, kvs[j].Key)
+	})
+	return kvs
+}
+
+// RangeSorted executes the given function for every KeyVal pair in the Hamt
+// in the order defined by less. Unlike Range, RangeSorted must collect and
+// sort every KeyVal pair before the first call to fn. For repeated sorted
+// traversals of the same HamtFunctional see SortedIndex.
+func (h *hamtBase) RangeSorted(
+	less func(a, b KeyI) bool,
+	fn func(KeyI, interface{}) bool,
+) {
+	for _, kv := range h.sortedKeyVals(less) {
+		if !fn(kv.Key, kv.Val) {
+			break
+		}
+	}
+}
+
+// sortedIndexSnapshots is the number of HamtFunctional snapshots a
+// SortedIndex keeps the sorted KeyVal pairs of.
+const sortedIndexSnapshots = 4
+
+// SortedIndex materializes the KeyVal pairs of a HamtFunctional sorted by a
+// comparator. The sorted slice is built lazily, on the first request for a
+// given snapshot, and cached; a HamtFunctional is never modified so the cache
+// never goes stale. Only the most recently used few snapshots are cached.
+//
+// A SortedIndex is safe for concurrent use.
+type SortedIndex struct {
+	less func(a, b KeyI) bool
+
+	mu      sync.Mutex
+	entries []*sortedIndexEntry // most recently used first
+}
+
+type sortedIndexEntry struct {
+	h    *HamtFunctional
+	kvs  []KeyVal
+	keys []KeyI
+}
+
+// NewSortedIndex constructs a SortedIndex ordering KeyVal pairs by less, for
+// example NaturalLess.
+func NewSortedIndex(less func(a, b KeyI) bool) *SortedIndex {
+	return &SortedIndex{less: less}
+}
+
+// entry returns the cache entry for h, building it if necessary.
+func (si *SortedIndex) entry(h *HamtFunctional) *sortedIndexEntry {
+	si.mu.Lock()
+	defer si.mu.Unlock()
+
+	for i, ent := range si.entries {
+		if ent.h == h {
+			copy(si.entries[1:i+1], si.entries[:i])
+			si.entries[0] = ent
+			return ent
+		}
+	}
+
+	var ent = &sortedIndexEntry{h: h, kvs: h.sortedKeyVals(si.less)}
+	ent.keys = make([]KeyI, len(ent.kvs))
+	for i, kv := range ent.kvs {
+		ent.keys[i] = kv.Key
+	}
+
+	if len(si.entries) < sortedIndexSnapshots {
+		si.entries = append(si.entries, nil)
+	}
+	copy(si.entries[1:], si.entries)
+	si.entries[0] = ent
+
+	return ent
+}
+
+// KeyVals returns the KeyVal pairs of h sorted by the SortedIndex comparator.
+// The returned slice is shared by every caller and must not be modified.
+func (si *SortedIndex) KeyVals(h *HamtFunctional) []KeyVal {
+	return si.entry(h).kvs
+}
+
+// Keys returns the keys of h sorted by the SortedIndex comparator. The
+// returned slice is shared by every caller and must not be modified.
+func (si *SortedIndex) Keys(h *HamtFunctional) []KeyI {
+	return si.entry(h).keys
+}
+
+// Range executes the given function for every KeyVal pair in h in sorted
+// order.
+func (si *SortedIndex) Range(h *HamtFunctional, fn func(KeyI, interface{}) bool) {
+	for _, kv := range si.entry(h).kvs {
+		if !fn(kv.Key, kv.Val) {
+			break
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package hamt64_test
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/lleo/go-hamt/hamt64"
+)
+
+func TestHamt64RangeSorted(t *testing.T) {
+	var name = "TestHamt64RangeSorted"
+	var kvs = KVS64[:10000]
+
+	var h, err = buildHamt64(name, kvs, Functional, TableOption)
+	if err != nil {
+		t.Fatalf("%s: buildHamt64 failed: %s", name, err)
+	}
+
+	var expected = make([]string, len(kvs))
+	for i, kv := range kvs {
+		expected[i] = string(kv.Key.(hamt64.StringKey))
+	}
+	sort.Strings(expected)
+
+	var i int
+	h.RangeSorted(hamt64.NaturalLess, func(k hamt64.KeyI, v interface{}) bool {
+		if string(k.(hamt64.StringKey)) != expected[i] {
+			t.Fatalf("%s: key #%d = %q; expected %q", name, i, k, expected[i])
+		}
+		i++
+		return true
+	})
+	if i != len(expected) {
+		t.Fatalf("%s: visited %d keys; expected %d", name, i, len(expected))
+	}
+
+	i = 0
+	h.RangeSorted(hamt64.NaturalLess, func(k hamt64.KeyI, v interface{}) bool {
+		i++
+		return i < 10
+	})
+	if i != 10 {
+		t.Fatalf("%s: RangeSorted did not stop early; i=%d", name, i)
+	}
+}
+
+func TestHamt64SortedIndex(t *testing.T) {
+	var name = "TestHamt64SortedIndex"
+
+	var h = hamt64.NewFunctional(TableOption)
+	for i := int64(1000); i > 0; i-- {
+		var nh, _ = h.Put(hamt64.Int64Key(i*7), i)
+		h = nh.(*hamt64.HamtFunctional)
+	}
+
+	var si = hamt64.NewSortedIndex(hamt64.NaturalLess)
+
+	var keys = si.Keys(h)
+	if len(keys) != 1000 {
+		t.Fatalf("%s: len(keys),%d != 1000", name, len(keys))
+	}
+	for i, k := range keys {
+		if k.(hamt64.Int64Key) != hamt64.Int64Key((i+1)*7) {
+			t.Fatalf("%s: keys[%d] = %v", name, i, k)
+		}
+	}
+
+	if &si.Keys(h)[0] != &keys[0] {
+		t.Fatalf("%s: sorted keys were not cached", name)
+	}
+
+	var nh, _ = h.Put(hamt64.Int64Key(0), 0)
+	var h2 = nh.(*hamt64.HamtFunctional)
+
+	var keys2 = si.Keys(h2)
+	if len(keys2) != 1001 || keys2[0].(hamt64.Int64Key) != 0 {
+		t.Fatalf("%s: new snapshot not reindexed: len=%d first=%v",
+			name, len(keys2), keys2[0])
+	}
+	if len(si.Keys(h)) != 1000 {
+		t.Fatalf("%s: old snapshot index changed", name)
+	}
+
+	var n int
+	si.Range(h2, func(k hamt64.KeyI, v interface{}) bool {
+		n++
+		return true
+	})
+	if n != 1001 {
+		t.Fatalf("%s: si.Range visited %d KeyVals; expected 1001", name, n)
+	}
+}
+
+func TestHamt64NaturalLess(t *testing.T) {
+	var tests = []struct {
+		a, b hamt64.KeyI
+		less bool
+	}{
+		{hamt64.StringKey("a"), hamt64.StringKey("b"), true},
+		{hamt64.StringKey("b"), hamt64.StringKey("a"), false},
+		{hamt64.ByteSliceKey("a"), hamt64.ByteSliceKey("ab"), true},
+		{hamt64.Int32Key(-1), hamt64.Int32Key(1), true},
+		{hamt64.Int64Key(-1), hamt64.Int64Key(1), true},
+		{hamt64.Uint32Key(1), hamt64.Uint32Key(2), true},
+		{hamt64.Uint64Key(2), hamt64.Uint64Key(1), false},
+		{hamt64.Int64Key(1), hamt64.Int64Key(1), false},
+		// different types are ordered by type name
+		{hamt64.Int64Key(9), hamt64.StringKey("0"), true},
+		{hamt64.StringKey("0"), hamt64.Int64Key(9), false},
+		// a HashedKey is ordered by the key it wraps
+		{hamt64.NewHashedKey(hamt64.StringKey("a")),
+			hamt64.NewHashedKey(hamt64.StringKey("b")), true},
+		{hamt64.NewHashedKey(hamt64.StringKey("b")),
+			hamt64.NewHashedKey(hamt64.StringKey("a")), false},
+	}
+
+	for _, test := range tests {
+		if hamt64.NaturalLess(test.a, test.b) != test.less {
+			t.Fatalf("TestHamt64NaturalLess: NaturalLess(%v, %v) != %v",
+				test.a, test.b, test.less)
+		}
+	}
+}
+
+// TestHamt64NaturalLessAllocs checks that comparing two keys of the same
+// OrderedKeyI type does not allocate, so sorting n keys does not allocate
+// O(n log n) type name strings.
+func TestHamt64NaturalLessAllocs(t *testing.T) {
+	var a, b hamt64.KeyI = hamt64.StringKey("a"), hamt64.StringKey("b")
+	var allocs = testing.AllocsPerRun(100, func() {
+		hamt64.NaturalLess(a, b)
+	})
+	if allocs != 0 {
+		t.Fatalf("TestHamt64NaturalLessAllocs: NaturalLess of two StringKeys "+
+			"made %v allocations; expected 0", allocs)
+	}
+}
@@ -80,7 +80,8 @@ func createSparseTable(depth uint, leaf1 leafI, leaf2 *flatLeaf) tableI {
 	} else { //idx1 == idx2
 		var node nodeI
 		if depth == maxDepth {
-			node = newCollisionLeaf(append(leaf1.keyVals(), leaf2.keyVals()...))
+			node = newCollisionLeaf(leaf1.Hash(),
+				append(leaf1.keyVals(), leaf2.keyVals()...))
 		} else {
 			node = createSparseTable(depth+1, leaf1, leaf2)
 		}
@@ -103,6 +104,7 @@ func downgradeToSparseTable(
 ) *sparseTable {
 	var nt = new(sparseTable)
 	nt.hashPath = hashPath
+	nt.depth = depth
 	//nt.nodeMap = 0
 	nt.nodes = make([]nodeI, len(ents), len(ents)+1)
 
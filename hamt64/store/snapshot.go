@@ -0,0 +1,99 @@
+package store
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"io/ioutil"
+	"os"
+
+	"github.com/lleo/go-hamt/hamt64"
+	"github.com/pkg/errors"
+)
+
+// A snapshot is the magic snapshotMagic, the number of entries as a uvarint,
+// the entries, each a uvarint key length, key, uvarint value length, and
+// value, and finally the CRC-32C of everything before it as a little endian
+// uint32.
+const snapshotMagic = "HAMTSNP1"
+
+// tmpSuffix marks a snapshot being written. It is renamed into place only
+// once it is complete and synced.
+const tmpSuffix = ".tmp"
+
+// writeSnapshot writes every entry of h to a new snapshot at path.
+func writeSnapshot(path string, h *hamt64.HamtFunctional) error {
+	var buf = []byte(snapshotMagic)
+	var n [binary.MaxVarintLen64]byte
+	buf = append(buf, n[:binary.PutUvarint(n[:], uint64(h.Nentries()))]...)
+
+	h.Range(func(k hamt64.KeyI, v interface{}) bool {
+		buf = appendBytes(buf, []byte(k.(hamt64.ByteSliceKey)))
+		buf = appendBytes(buf, v.([]byte))
+		return true
+	})
+
+	var sum [4]byte
+	binary.LittleEndian.PutUint32(sum[:], crc32.Checksum(buf, crcTable))
+	buf = append(buf, sum[:]...)
+
+	var tmp = path + tmpSuffix
+	var f, err = os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(buf)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+// readSnapshot loads the snapshot at path into a new HamtFunctional.
+func readSnapshot(path string, tblOpt int) (*hamt64.HamtFunctional, error) {
+	var data, err = ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < len(snapshotMagic)+4 ||
+		string(data[:len(snapshotMagic)]) != snapshotMagic {
+		return nil, errors.Errorf("%s: not a snapshot", path)
+	}
+	var body, sum = data[:len(data)-4], data[len(data)-4:]
+	if crc32.Checksum(body, crcTable) != binary.LittleEndian.Uint32(sum) {
+		return nil, errors.Errorf("%s: checksum mismatch", path)
+	}
+
+	var rest = body[len(snapshotMagic):]
+	var count, sz = binary.Uvarint(rest)
+	if sz <= 0 {
+		return nil, errors.Errorf("%s: bad entry count", path)
+	}
+	rest = rest[sz:]
+
+	var h = hamt64.NewTransient(tblOpt)
+	for i := uint64(0); i < count; i++ {
+		var key, val []byte
+		key, rest, err = readBytes(rest)
+		if err == nil {
+			val, rest, err = readBytes(rest)
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "%s: entry %d", path, i)
+		}
+		h.Put(hamt64.ByteSliceKey(key), val)
+	}
+	if len(rest) != 0 {
+		return nil, errors.Errorf("%s: %d trailing bytes", path, len(rest))
+	}
+
+	return h.ToFunctional().(*hamt64.HamtFunctional), nil
+}
@@ -0,0 +1,331 @@
+/*
+Package store is a durable key/value store whose in-memory state is a
+hamt64.HamtFunctional. Keys and values are byte slices.
+
+Every Put and Del is appended to a checksummed write-ahead log before it is
+applied. Periodically, and on Checkpoint, the whole state is written to a
+snapshot file and a new, empty log is started. Open recovers the state by
+loading the latest snapshot and replaying the log written after it.
+
+A crash may leave a partially written record at the end of the log. Open
+detects it by its length or checksum, truncates the log just before it, and
+carries on; only the operation being written at the time of the crash is lost.
+
+The files in the store directory are:
+
+	snapshot-<generation>     the state at the start of the generation
+	wal-<generation>          the operations since that snapshot
+
+where generation is a 16 digit hexadecimal number that increases by one with
+each snapshot.
+
+Reads never take a lock. Snapshot returns the current HamtFunctional, which is
+immutable, so any number of goroutines may read it while writers carry on.
+*/
+package store
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/lleo/go-hamt/hamt64"
+	"github.com/pkg/errors"
+)
+
+// Options configures a Store.
+type Options struct {
+	// TableOption is the hamt64 table option of the in-memory state;
+	// hamt64.HybridTables, hamt64.FixedTables, or hamt64.SparseTables.
+	TableOption int
+
+	// SnapshotEvery is the number of logged operations after which a snapshot
+	// is taken automatically. Zero means snapshots are only taken by
+	// Checkpoint.
+	SnapshotEvery int
+
+	// NoSync disables the fsync after every logged operation. Without it an
+	// operation is durable when Put or Del returns; with it an operating
+	// system crash may lose the most recent operations, though never corrupt
+	// the store.
+	NoSync bool
+}
+
+// Store is a durable key/value store. It is safe for concurrent use.
+type Store struct {
+	dir  string
+	opts Options
+
+	cur atomic.Value // *hamt64.HamtFunctional
+
+	mu      sync.Mutex // serializes writers
+	gen     uint64
+	wal     *walWriter
+	nlogged int
+	closed  bool
+}
+
+// Open opens the store in dir, creating dir and an empty store if necessary,
+// and recovers its state.
+func Open(dir string, opts Options) (*Store, error) {
+	var err = os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, errors.Wrap(err, "Open")
+	}
+
+	var s = &Store{dir: dir, opts: opts}
+
+	var snaps, wals []uint64
+	snaps, wals, err = s.listGenerations()
+	if err != nil {
+		return nil, errors.Wrap(err, "Open")
+	}
+
+	var h = hamt64.NewFunctional(opts.TableOption)
+	if len(snaps) > 0 {
+		s.gen = snaps[len(snaps)-1]
+		h, err = readSnapshot(s.path(snapshotPrefix, s.gen), opts.TableOption)
+		if err != nil {
+			return nil, errors.Wrap(err, "Open")
+		}
+	} else if len(wals) > 0 {
+		// A store that has never taken a snapshot starts from empty.
+		s.gen = wals[0]
+	}
+
+	var n int
+	h, n, err = replayWAL(s.path(walPrefix, s.gen), h)
+	if err != nil {
+		return nil, errors.Wrap(err, "Open")
+	}
+	s.nlogged = n
+
+	s.wal, err = openWAL(s.path(walPrefix, s.gen), !opts.NoSync)
+	if err != nil {
+		return nil, errors.Wrap(err, "Open")
+	}
+	s.cur.Store(h)
+
+	s.removeBefore(s.gen)
+
+	return s, nil
+}
+
+// Snapshot returns the current state. It never blocks.
+func (s *Store) Snapshot() *hamt64.HamtFunctional {
+	return s.cur.Load().(*hamt64.HamtFunctional)
+}
+
+// Get returns the value of key in the current state. The returned slice must
+// not be modified.
+func (s *Store) Get(key []byte) ([]byte, bool) {
+	var val, found = s.Snapshot().Get(hamt64.ByteSliceKey(key))
+	if !found {
+		return nil, false
+	}
+	return val.([]byte), true
+}
+
+// Nentries returns the number of keys in the current state.
+func (s *Store) Nentries() uint {
+	return s.Snapshot().Nentries()
+}
+
+// Put stores the (key,value) pair. Both slices are copied. It returns true if
+// the key was not already present.
+func (s *Store) Put(key, val []byte) (bool, error) {
+	var k, v = append([]byte(nil), key...), append([]byte(nil), val...)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var err = s.log(walRecord{op: opPut, key: k, val: v})
+	if err != nil {
+		return false, errors.Wrap(err, "Put")
+	}
+
+	var nh, added = s.Snapshot().Put(hamt64.ByteSliceKey(k), v)
+	s.cur.Store(nh.(*hamt64.HamtFunctional))
+
+	return added, s.maybeCheckpoint()
+}
+
+// Del deletes key. It returns true if the key was present.
+func (s *Store) Del(key []byte) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var h = s.Snapshot()
+	if _, found := h.Get(hamt64.ByteSliceKey(key)); !found {
+		return false, nil
+	}
+
+	var k = append([]byte(nil), key...)
+	var err = s.log(walRecord{op: opDel, key: k})
+	if err != nil {
+		return false, errors.Wrap(err, "Del")
+	}
+
+	var nh, _, _ = h.Del(hamt64.ByteSliceKey(k))
+	s.cur.Store(nh.(*hamt64.HamtFunctional))
+
+	return true, s.maybeCheckpoint()
+}
+
+// log appends r to the write-ahead log. The caller must hold s.mu.
+func (s *Store) log(r walRecord) error {
+	if s.closed {
+		return errors.New("store is closed")
+	}
+	var err = s.wal.append(r)
+	if err != nil {
+		return err
+	}
+	s.nlogged++
+	return nil
+}
+
+func (s *Store) maybeCheckpoint() error {
+	if s.opts.SnapshotEvery > 0 && s.nlogged >= s.opts.SnapshotEvery {
+		return errors.Wrap(s.checkpoint(), "automatic snapshot")
+	}
+	return nil
+}
+
+// Checkpoint writes a snapshot of the current state, starts a new empty
+// write-ahead log, and removes the files of the previous generation.
+func (s *Store) Checkpoint() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return errors.New("Checkpoint: store is closed")
+	}
+	return errors.Wrap(s.checkpoint(), "Checkpoint")
+}
+
+// checkpoint does the work of Checkpoint. The caller must hold s.mu.
+//
+// The new snapshot is complete on disk before the new log exists, and both
+// exist before the old files are removed, so a crash at any point leaves a
+// generation Open can recover.
+func (s *Store) checkpoint() error {
+	var gen = s.gen + 1
+
+	var err = writeSnapshot(s.path(snapshotPrefix, gen), s.Snapshot())
+	if err != nil {
+		return err
+	}
+
+	var wal *walWriter
+	wal, err = openWAL(s.path(walPrefix, gen), !s.opts.NoSync)
+	if err != nil {
+		return err
+	}
+	err = syncDir(s.dir)
+	if err != nil {
+		wal.close()
+		return err
+	}
+
+	s.wal.close()
+	s.wal = wal
+	s.gen = gen
+	s.nlogged = 0
+
+	s.removeBefore(gen)
+
+	return nil
+}
+
+// Close closes the write-ahead log. The Snapshots already returned remain
+// usable; further writes fail.
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	return errors.Wrap(s.wal.close(), "Close")
+}
+
+const (
+	snapshotPrefix = "snapshot-"
+	walPrefix      = "wal-"
+)
+
+func (s *Store) path(prefix string, gen uint64) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%s%016x", prefix, gen))
+}
+
+// listGenerations returns the generations of the snapshot and wal files in
+// the store directory, in increasing order.
+func (s *Store) listGenerations() (snaps, wals []uint64, err error) {
+	var f *os.File
+	f, err = os.Open(s.dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	var names []string
+	names, err = f.Readdirnames(-1)
+	f.Close()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, name := range names {
+		var gen uint64
+		if _, e := fmt.Sscanf(name, snapshotPrefix+"%016x", &gen); e == nil &&
+			name == filepath.Base(s.path(snapshotPrefix, gen)) {
+			snaps = append(snaps, gen)
+		} else if _, e := fmt.Sscanf(name, walPrefix+"%016x", &gen); e == nil &&
+			name == filepath.Base(s.path(walPrefix, gen)) {
+			wals = append(wals, gen)
+		}
+	}
+
+	sort.Slice(snaps, func(i, j int) bool { return snaps[i] < snaps[j] })
+	sort.Slice(wals, func(i, j int) bool { return wals[i] < wals[j] })
+
+	return snaps, wals, nil
+}
+
+// removeBefore removes the snapshot and wal files of generations before gen,
+// and any partially written snapshot. Failures are ignored; the files are
+// removed again by the next Open or Checkpoint.
+func (s *Store) removeBefore(gen uint64) {
+	var snaps, wals, err = s.listGenerations()
+	if err != nil {
+		return
+	}
+	for _, g := range snaps {
+		if g < gen {
+			os.Remove(s.path(snapshotPrefix, g))
+		}
+	}
+	for _, g := range wals {
+		if g < gen {
+			os.Remove(s.path(walPrefix, g))
+		}
+	}
+	var tmps, _ = filepath.Glob(filepath.Join(s.dir, "*"+tmpSuffix))
+	for _, tmp := range tmps {
+		os.Remove(tmp)
+	}
+}
+
+// syncDir fsyncs the directory dir, making the creation and renaming of the
+// files in it durable.
+func syncDir(dir string) error {
+	var f, err = os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return f.Sync()
+}
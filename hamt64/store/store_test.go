@@ -0,0 +1,247 @@
+package store_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/lleo/go-hamt/hamt64"
+	"github.com/lleo/go-hamt/hamt64/store"
+)
+
+func tempDir(t *testing.T) string {
+	var dir, err = ioutil.TempDir("", "hamt64-store")
+	if err != nil {
+		t.Fatalf("TempDir failed: %s", err)
+	}
+	return dir
+}
+
+func key(i int) []byte { return []byte(fmt.Sprint("key", i)) }
+func val(i int) []byte { return []byte(fmt.Sprint("val", i)) }
+
+// check verifies that s holds exactly the entries of expected.
+func check(t *testing.T, name string, s *store.Store, expected map[string]string) {
+	if s.Nentries() != uint(len(expected)) {
+		t.Fatalf("%s: Nentries(),%d != %d", name, s.Nentries(), len(expected))
+	}
+	for k, v := range expected {
+		var got, found = s.Get([]byte(k))
+		if !found || string(got) != v {
+			t.Fatalf("%s: Get(%q) = %q, %t; expected %q", name, k, got, found, v)
+		}
+	}
+}
+
+// walPath returns the path of the only write-ahead log in dir.
+func walPath(t *testing.T, dir string) string {
+	var wals, _ = filepath.Glob(filepath.Join(dir, "wal-*"))
+	if len(wals) != 1 {
+		t.Fatalf("expected one write-ahead log; found %v", wals)
+	}
+	return wals[0]
+}
+
+func TestStoreRecovery(t *testing.T) {
+	var name = "TestStoreRecovery"
+	var dir = tempDir(t)
+	defer os.RemoveAll(dir)
+
+	var s, err = store.Open(dir, store.Options{SnapshotEvery: 300})
+	if err != nil {
+		t.Fatalf("%s: Open failed: %s", name, err)
+	}
+
+	var expected = make(map[string]string)
+	for i := 0; i < 1000; i++ {
+		if _, err = s.Put(key(i), val(i)); err != nil {
+			t.Fatalf("%s: Put failed: %s", name, err)
+		}
+		expected[string(key(i))] = string(val(i))
+	}
+	for i := 0; i < 1000; i += 3 {
+		var deleted bool
+		deleted, err = s.Del(key(i))
+		if err != nil || !deleted {
+			t.Fatalf("%s: Del(%q) = %t, %v", name, key(i), deleted, err)
+		}
+		delete(expected, string(key(i)))
+	}
+	if deleted, _ := s.Del([]byte("missing")); deleted {
+		t.Fatalf("%s: Del of a missing key returned true", name)
+	}
+	var added bool
+	added, err = s.Put(key(1), []byte("replaced"))
+	if err != nil || added {
+		t.Fatalf("%s: replacing Put = %t, %v", name, added, err)
+	}
+	expected[string(key(1))] = "replaced"
+	check(t, name, s, expected)
+
+	// Reopening without Close recovers from the snapshot and the log.
+	s, err = store.Open(dir, store.Options{SnapshotEvery: 300})
+	if err != nil {
+		t.Fatalf("%s: reOpen failed: %s", name, err)
+	}
+	check(t, name+": reopened", s, expected)
+	if err = s.Snapshot().Validate(); err != nil {
+		t.Fatalf("%s: recovered Hamt Validate failed: %s", name, err)
+	}
+
+	var snaps, _ = filepath.Glob(filepath.Join(dir, "snapshot-*"))
+	if len(snaps) != 1 {
+		t.Fatalf("%s: old generations not removed: %v", name, snaps)
+	}
+
+	if err = s.Checkpoint(); err != nil {
+		t.Fatalf("%s: Checkpoint failed: %s", name, err)
+	}
+	if err = s.Close(); err != nil {
+		t.Fatalf("%s: Close failed: %s", name, err)
+	}
+	if _, err = s.Put(key(0), val(0)); err == nil {
+		t.Fatalf("%s: Put after Close did not fail", name)
+	}
+
+	s, err = store.Open(dir, store.Options{})
+	if err != nil {
+		t.Fatalf("%s: Open after Checkpoint failed: %s", name, err)
+	}
+	check(t, name+": after Checkpoint", s, expected)
+	s.Close()
+}
+
+// TestStoreTornWrite cuts the write-ahead log at every byte offset within its
+// last records, and flips bits in them, simulating a crash part way through
+// writing them. Open must recover every whole record before the damage.
+func TestStoreTornWrite(t *testing.T) {
+	var name = "TestStoreTornWrite"
+	var dir = tempDir(t)
+	defer os.RemoveAll(dir)
+
+	var s, err = store.Open(dir, store.Options{NoSync: true})
+	if err != nil {
+		t.Fatalf("%s: Open failed: %s", name, err)
+	}
+	var sizes []int64 // log size after each Put
+	for i := 0; i < 20; i++ {
+		s.Put(key(i), val(i))
+		var fi, _ = os.Stat(walPath(t, dir))
+		sizes = append(sizes, fi.Size())
+	}
+	s.Close()
+
+	var path = walPath(t, dir)
+	var good, _ = ioutil.ReadFile(path)
+
+	var recoverAt = func(desc string, data []byte, n int) {
+		if err := ioutil.WriteFile(path, data, 0644); err != nil {
+			t.Fatalf("%s: WriteFile failed: %s", name, err)
+		}
+		var s, err = store.Open(dir, store.Options{})
+		if err != nil {
+			t.Fatalf("%s: %s: Open failed: %s", name, desc, err)
+		}
+		var expected = make(map[string]string)
+		for i := 0; i < n; i++ {
+			expected[string(key(i))] = string(val(i))
+		}
+		check(t, name+": "+desc, s, expected)
+
+		// The store keeps working after recovery.
+		s.Put([]byte("after"), []byte("recovery"))
+		s.Close()
+		s, err = store.Open(dir, store.Options{})
+		if err != nil {
+			t.Fatalf("%s: %s: second Open failed: %s", name, desc, err)
+		}
+		expected["after"] = "recovery"
+		check(t, name+": "+desc+": second Open", s, expected)
+		s.Close()
+	}
+
+	for cut := sizes[16]; cut < int64(len(good)); cut++ {
+		// records 0..n-1 end at or before the cut
+		var n = 0
+		for n < len(sizes) && sizes[n] <= cut {
+			n++
+		}
+		recoverAt(fmt.Sprint("cut at ", cut), good[:cut], n)
+	}
+
+	for off := sizes[18]; off < sizes[19]; off++ {
+		var data = append([]byte(nil), good...)
+		data[off] ^= 0x40
+		recoverAt(fmt.Sprint("bit flip at ", off), data, 19)
+	}
+
+	recoverAt("cut in magic", good[:3], 0)
+}
+
+func TestStoreCorruptSnapshot(t *testing.T) {
+	var name = "TestStoreCorruptSnapshot"
+	var dir = tempDir(t)
+	defer os.RemoveAll(dir)
+
+	var s, _ = store.Open(dir, store.Options{})
+	for i := 0; i < 100; i++ {
+		s.Put(key(i), val(i))
+	}
+	s.Checkpoint()
+	s.Close()
+
+	var snaps, _ = filepath.Glob(filepath.Join(dir, "snapshot-*"))
+	var data, _ = ioutil.ReadFile(snaps[0])
+	data[len(data)/2] ^= 1
+	ioutil.WriteFile(snaps[0], data, 0644)
+
+	if _, err := store.Open(dir, store.Options{}); err == nil {
+		t.Fatalf("%s: Open of a corrupt snapshot did not fail", name)
+	}
+}
+
+// TestStoreConcurrentReads reads Snapshots while a writer Puts.
+func TestStoreConcurrentReads(t *testing.T) {
+	var name = "TestStoreConcurrentReads"
+	var dir = tempDir(t)
+	defer os.RemoveAll(dir)
+
+	var s, _ = store.Open(dir, store.Options{NoSync: true, SnapshotEvery: 100,
+		TableOption: hamt64.HybridTables})
+	defer s.Close()
+
+	var wg sync.WaitGroup
+	var done = make(chan struct{})
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				// every Snapshot is a consistent prefix of the Puts
+				var h = s.Snapshot()
+				var n = int(h.Nentries())
+				if n > 0 {
+					if _, found := h.Get(hamt64.ByteSliceKey(key(n - 1))); !found {
+						t.Errorf("%s: Snapshot of %d entries lacks %q",
+							name, n, key(n-1))
+						return
+					}
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < 500; i++ {
+		s.Put(key(i), val(i))
+	}
+	close(done)
+	wg.Wait()
+}
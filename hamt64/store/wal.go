@@ -0,0 +1,266 @@
+package store
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/lleo/go-hamt/hamt64"
+	"github.com/pkg/errors"
+)
+
+// The write-ahead log is the magic walMagic followed by records. Each record
+// is
+//
+//	length   uint32, little endian; the length of the payload
+//	checksum uint32, little endian; CRC-32C of the payload
+//	payload  op byte, uvarint key length, key, and for opPut uvarint value
+//	         length, value
+//
+// A record that is short or fails its checksum marks the end of the log.
+const walMagic = "HAMTWAL1"
+
+const walHeaderSize = 8
+
+// maxRecordSize bounds the length field of a record, so a corrupt length is
+// recognised rather than allocated.
+const maxRecordSize = 1 << 30
+
+const (
+	opPut byte = 1
+	opDel byte = 2
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+type walRecord struct {
+	op  byte
+	key []byte
+	val []byte
+}
+
+func (r walRecord) encode() []byte {
+	var payload = make([]byte, 0,
+		1+2*binary.MaxVarintLen64+len(r.key)+len(r.val))
+	payload = append(payload, r.op)
+	payload = appendBytes(payload, r.key)
+	if r.op == opPut {
+		payload = appendBytes(payload, r.val)
+	}
+
+	var rec = make([]byte, walHeaderSize, walHeaderSize+len(payload))
+	binary.LittleEndian.PutUint32(rec[0:], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(rec[4:], crc32.Checksum(payload, crcTable))
+	return append(rec, payload...)
+}
+
+func decodeRecord(payload []byte) (walRecord, error) {
+	var r walRecord
+	if len(payload) == 0 {
+		return r, errors.New("empty record")
+	}
+	r.op, payload = payload[0], payload[1:]
+
+	var err error
+	r.key, payload, err = readBytes(payload)
+	if err != nil {
+		return r, err
+	}
+	switch r.op {
+	case opPut:
+		r.val, payload, err = readBytes(payload)
+		if err != nil {
+			return r, err
+		}
+	case opDel:
+	default:
+		return r, errors.Errorf("unknown op %d", r.op)
+	}
+	if len(payload) != 0 {
+		return r, errors.Errorf("%d trailing bytes", len(payload))
+	}
+	return r, nil
+}
+
+// appendBytes appends b to buf prefixed with its uvarint length.
+func appendBytes(buf, b []byte) []byte {
+	var n [binary.MaxVarintLen64]byte
+	buf = append(buf, n[:binary.PutUvarint(n[:], uint64(len(b)))]...)
+	return append(buf, b...)
+}
+
+// readBytes reads a slice written by appendBytes from the front of buf and
+// returns it and the rest of buf.
+func readBytes(buf []byte) ([]byte, []byte, error) {
+	var n, sz = binary.Uvarint(buf)
+	if sz <= 0 || n > uint64(len(buf)-sz) {
+		return nil, nil, errors.New("bad length")
+	}
+	buf = buf[sz:]
+	return buf[:n:n], buf[n:], nil
+}
+
+// walFile is the part of an *os.File a walWriter uses.
+type walFile interface {
+	io.Writer
+	Sync() error
+	Truncate(size int64) error
+	Close() error
+}
+
+type walWriter struct {
+	f    walFile
+	sync bool
+	size int64 // the end of the last whole record
+	err  error // set once the log can not be appended to
+}
+
+// openWAL opens the log at path for appending, creating it if necessary.
+// replayWAL must already have truncated any torn record from its end.
+func openWAL(path string, sync bool) (*walWriter, error) {
+	var f, err = os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	var fi os.FileInfo
+	fi, err = f.Stat()
+	var size int64
+	if err == nil {
+		size = fi.Size()
+	}
+	if err == nil && size == 0 {
+		_, err = f.Write([]byte(walMagic))
+		if err == nil {
+			err = f.Sync()
+		}
+		size = int64(len(walMagic))
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return &walWriter{f: f, sync: sync, size: size}, nil
+}
+
+// append writes r to the end of the log. If that fails, whatever part of r was
+// written is truncated away, so the next record follows the last whole one;
+// replayWAL stops at the first torn record, so a torn record left in the
+// middle of the log would lose every record after it. If the truncation fails
+// too, every later append fails.
+func (w *walWriter) append(r walRecord) error {
+	if w.err != nil {
+		return w.err
+	}
+
+	var rec = r.encode()
+	var _, err = w.f.Write(rec)
+	if err == nil && w.sync {
+		err = w.f.Sync()
+	}
+	if err != nil {
+		var terr = w.f.Truncate(w.size)
+		if terr == nil && w.sync {
+			terr = w.f.Sync()
+		}
+		if terr != nil {
+			w.err = errors.Wrapf(terr,
+				"write-ahead log not truncated after failed append (%s)", err)
+		}
+		return err
+	}
+
+	w.size += int64(len(rec))
+	return nil
+}
+
+func (w *walWriter) close() error {
+	return w.f.Close()
+}
+
+// replayWAL applies the records of the log at path to h, which must not be
+// shared as it is modified in place, and returns the result and the number of
+// records applied. A missing log is empty. If the
+// log ends in a torn or corrupt record the log is truncated just before it.
+func replayWAL(path string, h *hamt64.HamtFunctional) (*hamt64.HamtFunctional, int, error) {
+	var data, err = ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return h, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if len(data) < len(walMagic) {
+		// torn while writing the magic; start the log again
+		return h, 0, truncate(path, 0)
+	}
+	if string(data[:len(walMagic)]) != walMagic {
+		return nil, 0, errors.Errorf("%s: not a write-ahead log", path)
+	}
+
+	var nh = h.ToTransient()
+	var n int
+	var off = len(walMagic)
+	for off < len(data) {
+		var r, size, ok = nextRecord(data[off:])
+		if !ok {
+			return nh.ToFunctional().(*hamt64.HamtFunctional), n,
+				truncate(path, int64(off))
+		}
+
+		if r.op == opPut {
+			nh, _ = nh.Put(hamt64.ByteSliceKey(r.key), r.val)
+		} else {
+			nh, _, _ = nh.Del(hamt64.ByteSliceKey(r.key))
+		}
+		n++
+		off += size
+	}
+
+	return nh.ToFunctional().(*hamt64.HamtFunctional), n, nil
+}
+
+// nextRecord decodes the record at the front of buf and returns it and its
+// size. ok is false if buf does not start with a whole, valid record.
+func nextRecord(buf []byte) (r walRecord, size int, ok bool) {
+	if len(buf) < walHeaderSize {
+		return r, 0, false
+	}
+	var length = binary.LittleEndian.Uint32(buf[0:])
+	var sum = binary.LittleEndian.Uint32(buf[4:])
+	if length > maxRecordSize || int(length) > len(buf)-walHeaderSize {
+		return r, 0, false
+	}
+
+	var payload = buf[walHeaderSize : walHeaderSize+int(length)]
+	if crc32.Checksum(payload, crcTable) != sum {
+		return r, 0, false
+	}
+
+	var err error
+	r, err = decodeRecord(payload)
+	if err != nil {
+		return r, 0, false
+	}
+
+	return r, walHeaderSize + int(length), true
+}
+
+func truncate(path string, size int64) error {
+	var f, err = os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	err = f.Truncate(size)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,105 @@
+package store
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+// failingFile is a walFile whose next Write writes only half of its argument
+// and fails, and whose Truncate fails if failTruncate is set.
+type failingFile struct {
+	walFile
+	failWrite    bool
+	failTruncate bool
+}
+
+func (f *failingFile) Write(b []byte) (int, error) {
+	if !f.failWrite {
+		return f.walFile.Write(b)
+	}
+	f.failWrite = false
+	var n, _ = f.walFile.Write(b[:len(b)/2])
+	return n, errors.New("injected short write")
+}
+
+func (f *failingFile) Truncate(size int64) error {
+	if f.failTruncate {
+		return errors.New("injected Truncate failure")
+	}
+	return f.walFile.Truncate(size)
+}
+
+// TestStoreShortWrite checks that a short write in the middle of the log only
+// loses the operation that failed, not the ones logged after it.
+func TestStoreShortWrite(t *testing.T) {
+	var name = "TestStoreShortWrite"
+	var dir, err = ioutil.TempDir("", "hamt64-store")
+	if err != nil {
+		t.Fatalf("%s: TempDir failed: %s", name, err)
+	}
+	defer os.RemoveAll(dir)
+
+	var s *Store
+	s, err = Open(dir, Options{})
+	if err != nil {
+		t.Fatalf("%s: Open failed: %s", name, err)
+	}
+	var ff = &failingFile{walFile: s.wal.f}
+	s.wal.f = ff
+
+	for i := 0; i < 10; i++ {
+		var k, v = []byte(fmt.Sprint("key", i)), []byte(fmt.Sprint("val", i))
+		ff.failWrite = i == 5
+		_, err = s.Put(k, v)
+		if (err != nil) != (i == 5) {
+			t.Fatalf("%s: Put #%d returned %v", name, i, err)
+		}
+	}
+	s.Close()
+
+	s, err = Open(dir, Options{})
+	if err != nil {
+		t.Fatalf("%s: reOpen failed: %s", name, err)
+	}
+	defer s.Close()
+	for i := 0; i < 10; i++ {
+		var _, found = s.Get([]byte(fmt.Sprint("key", i)))
+		if found != (i != 5) {
+			t.Fatalf("%s: after reOpen Get(key%d) found=%t", name, i, found)
+		}
+	}
+}
+
+// TestStoreShortWriteNoTruncate checks that a Store whose log can not be
+// repaired after a short write refuses every later write.
+func TestStoreShortWriteNoTruncate(t *testing.T) {
+	var name = "TestStoreShortWriteNoTruncate"
+	var dir, err = ioutil.TempDir("", "hamt64-store")
+	if err != nil {
+		t.Fatalf("%s: TempDir failed: %s", name, err)
+	}
+	defer os.RemoveAll(dir)
+
+	var s *Store
+	s, err = Open(dir, Options{})
+	if err != nil {
+		t.Fatalf("%s: Open failed: %s", name, err)
+	}
+	defer s.Close()
+	s.wal.f = &failingFile{walFile: s.wal.f, failWrite: true,
+		failTruncate: true}
+
+	if _, err = s.Put([]byte("a"), []byte("1")); err == nil {
+		t.Fatalf("%s: Put with a short write did not fail", name)
+	}
+	if _, err = s.Put([]byte("b"), []byte("2")); err == nil {
+		t.Fatalf("%s: Put after a failed truncation did not fail", name)
+	}
+	if s.Nentries() != 0 {
+		t.Fatalf("%s: Nentries(),%d != 0", name, s.Nentries())
+	}
+}
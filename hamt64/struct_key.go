@@ -0,0 +1,208 @@
+package hamt64
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// StructKey is a key derived from a struct value. Its HashVal and Equals
+// method use only the exported fields of comparable type (see
+// reflect.Type.Comparable); unexported fields and fields of slice, map, or
+// func type are ignored. Two StructKeys are Equal if their structs are of the
+// same type and every used field is ==.
+//
+// The fields to use are worked out once per struct type and cached, and the
+// HashVal is calculated once, by NewStructKey().
+type StructKey struct {
+	v  reflect.Value
+	hv HashVal
+}
+
+// NewStructKey constructs a StructKey from v, which must be a struct or a
+// pointer to a struct. The struct is copied, so later changes to it do not
+// change the key. NewStructKey panics if v is not a struct, or if the struct
+// has no exported fields of comparable type.
+func NewStructKey(v interface{}) StructKey {
+	var rv = reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		panic(errors.Errorf("NewStructKey: %T is not a struct", v))
+	}
+
+	var plan = structPlanFor(rv.Type())
+	if len(plan) == 0 {
+		panic(errors.Errorf(
+			"NewStructKey: %s has no exported fields of comparable type",
+			rv.Type()))
+	}
+
+	// copy the struct so the key is immutable
+	var cp = reflect.New(rv.Type()).Elem()
+	cp.Set(rv)
+
+	return StructKey{v: cp, hv: hashStruct(newHashState(), cp, plan).sum()}
+}
+
+// Value returns a copy of the struct the StructKey was constructed from.
+func (sk StructKey) Value() interface{} {
+	return sk.v.Interface()
+}
+
+func (sk StructKey) Hash() HashVal {
+	return sk.hv
+}
+
+func (sk StructKey) Equals(K KeyI) bool {
+	var k, ok = K.(StructKey)
+	if !ok || k.v.Type() != sk.v.Type() {
+		return false
+	}
+	if k.hv != sk.hv {
+		return false
+	}
+	for _, i := range structPlanFor(sk.v.Type()) {
+		if !equalValue(sk.v.Field(i), k.v.Field(i)) {
+			return false
+		}
+	}
+	return true
+}
+
+func (sk StructKey) String() string {
+	return fmt.Sprintf("%+v", sk.v.Interface())
+}
+
+// structPlan is the list of field indexes of a struct type used by StructKey.
+type structPlan []int
+
+var structPlans = struct {
+	sync.RWMutex
+	m map[reflect.Type]structPlan
+}{m: make(map[reflect.Type]structPlan)}
+
+// structPlanFor returns the cached structPlan of the struct type t, building
+// it on first use.
+func structPlanFor(t reflect.Type) structPlan {
+	structPlans.RLock()
+	var plan, ok = structPlans.m[t]
+	structPlans.RUnlock()
+	if ok {
+		return plan
+	}
+
+	plan = structPlan{}
+	for i := 0; i < t.NumField(); i++ {
+		var f = t.Field(i)
+		if f.PkgPath != "" || !f.Type.Comparable() {
+			continue // unexported or not comparable
+		}
+		plan = append(plan, i)
+	}
+
+	structPlans.Lock()
+	structPlans.m[t] = plan
+	structPlans.Unlock()
+
+	return plan
+}
+
+func hashStruct(h hashState, v reflect.Value, plan structPlan) hashState {
+	for _, i := range plan {
+		h = hashValue(h, v.Field(i))
+	}
+	return h
+}
+
+// hashValue adds the value v, of a comparable type, to the hash state h. Values
+// that are == always produce the same hash state.
+func hashValue(h hashState, v reflect.Value) hashState {
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			return h.addByte(1)
+		}
+		return h.addByte(0)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return h.addUint64(uint64(v.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Uint64, reflect.Uintptr:
+		return h.addUint64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		return h.addUint64(floatBits(v.Float()))
+	case reflect.Complex64, reflect.Complex128:
+		var c = v.Complex()
+		return h.addUint64(floatBits(real(c))).addUint64(floatBits(imag(c)))
+	case reflect.String:
+		// the length separates adjacent strings; ("ab","c") vs ("a","bc")
+		return h.addUint64(uint64(v.Len())).addString(v.String())
+	case reflect.Ptr, reflect.Chan, reflect.UnsafePointer:
+		return h.addUint64(uint64(v.Pointer()))
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			h = hashValue(h, v.Index(i))
+		}
+		return h
+	case reflect.Struct:
+		return hashStruct(h, v, structPlanFor(v.Type()))
+	case reflect.Interface:
+		if v.IsNil() {
+			return h.addByte(0)
+		}
+		return hashValue(h.addString(v.Elem().Type().String()), v.Elem())
+	}
+	// Not comparable; == would panic, so this can not be a valid key field.
+	panic(errors.Errorf("StructKey: can not hash a value of type %s", v.Type()))
+}
+
+// equalValue reports whether the values a and b, of the same comparable type,
+// are ==. Unlike comparing a.Interface() and b.Interface() it does not
+// allocate.
+func equalValue(a, b reflect.Value) bool {
+	switch a.Kind() {
+	case reflect.Bool:
+		return a.Bool() == b.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return a.Int() == b.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Uint64, reflect.Uintptr:
+		return a.Uint() == b.Uint()
+	case reflect.Float32, reflect.Float64:
+		return a.Float() == b.Float()
+	case reflect.Complex64, reflect.Complex128:
+		return a.Complex() == b.Complex()
+	case reflect.String:
+		return a.String() == b.String()
+	case reflect.Ptr, reflect.Chan, reflect.UnsafePointer:
+		return a.Pointer() == b.Pointer()
+	case reflect.Array:
+		for i := 0; i < a.Len(); i++ {
+			if !equalValue(a.Index(i), b.Index(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Struct:
+		// == compares every field, exported or not
+		for i := 0; i < a.NumField(); i++ {
+			if !equalValue(a.Field(i), b.Field(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Interface:
+		if a.IsNil() || b.IsNil() {
+			return a.IsNil() && b.IsNil()
+		}
+		if a.Elem().Type() != b.Elem().Type() {
+			return false
+		}
+		return equalValue(a.Elem(), b.Elem())
+	}
+	// Not comparable; == would panic.
+	panic(errors.Errorf("StructKey: can not compare values of type %s", a.Type()))
+}
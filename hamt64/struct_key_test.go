@@ -0,0 +1,131 @@
+package hamt64_test
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/lleo/go-hamt/hamt64"
+)
+
+func TestHamt64TupleKey(t *testing.T) {
+	var name = "TestHamt64TupleKey"
+
+	var tuple = func(tenant string, resource string, version int64) hamt64.TupleKey {
+		return hamt64.TupleKey{
+			hamt64.StringKey(tenant),
+			hamt64.StringKey(resource),
+			hamt64.Int64Key(version),
+		}
+	}
+
+	var a, b = tuple("acme", "db", 1), tuple("acme", "db", 1)
+	if !a.Equals(b) || a.Hash() != b.Hash() {
+		t.Fatalf("%s: equal tuples %v and %v not Equal or hash differently",
+			name, a, b)
+	}
+	if a.Equals(tuple("acme", "db", 2)) || a.Equals(a[:2]) {
+		t.Fatalf("%s: different tuples Equal", name)
+	}
+	if !a[:2].Less(a) || !a.Less(tuple("acme", "db", 2)) ||
+		tuple("acme", "fs", 0).Less(a) {
+		t.Fatalf("%s: tuples not ordered lexicographically", name)
+	}
+
+	var h = hamt64.New(Functional, TableOption)
+	for tenant := 0; tenant < 10; tenant++ {
+		for resource := 0; resource < 10; resource++ {
+			for version := int64(0); version < 10; version++ {
+				var k = tuple(fmt.Sprint("t", tenant), fmt.Sprint("r", resource),
+					version)
+				h, _ = h.Put(k, version)
+			}
+		}
+	}
+	if h.Nentries() != 1000 {
+		t.Fatalf("%s: h.Nentries(),%d != 1000", name, h.Nentries())
+	}
+	var err = h.Validate()
+	if err != nil {
+		t.Fatalf("%s: Validate() failed: %s", name, err)
+	}
+	var v, found = h.Get(tuple("t3", "r7", 5))
+	if !found || v != int64(5) {
+		t.Fatalf("%s: Get(%v) = %v, %v", name, tuple("t3", "r7", 5), v, found)
+	}
+}
+
+type structKeyVal struct {
+	Tenant   string
+	Resource string
+	Version  int64
+	Weight   float64
+	Tags     []string // not comparable; ignored
+	private  int      // unexported; ignored
+}
+
+func TestHamt64StructKey(t *testing.T) {
+	var name = "TestHamt64StructKey"
+
+	var a = hamt64.NewStructKey(structKeyVal{"acme", "db", 1, 0, []string{"x"}, 1})
+	var b = hamt64.NewStructKey(&structKeyVal{"acme", "db", 1,
+		math.Copysign(0, -1), nil, 2})
+	if !a.Equals(b) || a.Hash() != b.Hash() {
+		t.Fatalf("%s: %v and %v not Equal or hash differently", name, a, b)
+	}
+
+	var c = hamt64.NewStructKey(structKeyVal{Tenant: "acme", Resource: "db",
+		Version: 2})
+	if a.Equals(c) {
+		t.Fatalf("%s: %v Equals %v", name, a, c)
+	}
+
+	// ("ab","c") and ("a","bc") must not hash the same
+	var d = hamt64.NewStructKey(structKeyVal{Tenant: "ab", Resource: "c"})
+	var e = hamt64.NewStructKey(structKeyVal{Tenant: "a", Resource: "bc"})
+	if d.Hash() == e.Hash() {
+		t.Fatalf("%s: adjacent string fields are not separated", name)
+	}
+
+	var h = hamt64.New(Functional, TableOption)
+	for i := 0; i < 1000; i++ {
+		var k = hamt64.NewStructKey(structKeyVal{
+			Tenant:  fmt.Sprint("t", i%10),
+			Version: int64(i),
+		})
+		h, _ = h.Put(k, i)
+	}
+	var err = h.Validate()
+	if err != nil {
+		t.Fatalf("%s: Validate() failed: %s", name, err)
+	}
+	var v, found = h.Get(hamt64.NewStructKey(structKeyVal{Tenant: "t7",
+		Version: 317, Tags: []string{"ignored"}}))
+	if !found || v != 317 {
+		t.Fatalf("%s: Get() = %v, %v", name, v, found)
+	}
+
+	var kv = hamt64.NewStructKey(structKeyVal{Tenant: "t"}).Value().(structKeyVal)
+	if kv.Tenant != "t" {
+		t.Fatalf("%s: Value() = %+v", name, kv)
+	}
+}
+
+func TestHamt64StructKeyPanics(t *testing.T) {
+	type noFields struct {
+		a int
+		B []int
+	}
+
+	for _, v := range []interface{}{42, noFields{}, (*structKeyVal)(nil)} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("TestHamt64StructKeyPanics: NewStructKey(%#v) "+
+						"did not panic", v)
+				}
+			}()
+			hamt64.NewStructKey(v)
+		}()
+	}
+}
@@ -0,0 +1,169 @@
+package hamt64
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// ErrConflict is the cause of the error returned by Txn.Commit when a key the
+// transaction touched was changed by another commit since Begin. Test for it
+// with errors.Cause(err) == ErrConflict.
+var ErrConflict = errors.New("transaction conflict")
+
+// Ref is a shared reference to a HamtFunctional that is updated atomically by
+// committing transactions. Readers simply Load the current version; as it is a
+// HamtFunctional it can be read without further locking.
+//
+// A Ref is safe for concurrent use.
+type Ref struct {
+	mu sync.RWMutex
+	h  *HamtFunctional
+}
+
+// NewRef constructs a Ref whose current version is h.
+func NewRef(h *HamtFunctional) *Ref {
+	return &Ref{h: h}
+}
+
+// Load returns the current version.
+func (r *Ref) Load() *HamtFunctional {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.h
+}
+
+// Begin starts a transaction on the current version of r.
+func (r *Ref) Begin() *Txn {
+	var base = r.Load()
+	return &Txn{
+		ref:    r,
+		base:   base,
+		writes: NewTransient(HybridTables),
+		reads:  NewTransient(HybridTables),
+	}
+}
+
+// Update runs fn in a new transaction and commits it, starting again from the
+// then current version each time the commit fails with a conflict. If fn
+// returns an error the transaction is abandoned and the error returned.
+func (r *Ref) Update(fn func(tx *Txn) error) (*HamtFunctional, error) {
+	for {
+		var tx = r.Begin()
+		var err = fn(tx)
+		if err != nil {
+			return nil, err
+		}
+		var h *HamtFunctional
+		h, err = tx.Commit()
+		if errors.Cause(err) != ErrConflict {
+			return h, err
+		}
+	}
+}
+
+// Txn is a transaction started by Ref.Begin. It reads from the version of the
+// Ref current at Begin, overlaid with its own writes, which are private to the
+// Txn until Commit.
+//
+// A Txn is not safe for concurrent use, and must not be used after Commit.
+type Txn struct {
+	ref    *Ref
+	base   *HamtFunctional
+	writes *HamtTransient // deleted keys hold txnDeleted
+	reads  *HamtTransient // keys read from base
+	done   bool
+}
+
+// txnTombstone is the value of a key deleted by a Txn.
+type txnTombstone struct{}
+
+var txnDeleted interface{} = txnTombstone{}
+
+// Base returns the version the Txn started from.
+func (tx *Txn) Base() *HamtFunctional {
+	return tx.base
+}
+
+// Get retrieves the value related to the key as seen by the Txn.
+func (tx *Txn) Get(key KeyI) (interface{}, bool) {
+	if val, found := tx.writes.Get(key); found {
+		if val == txnDeleted {
+			return nil, false
+		}
+		return val, true
+	}
+	tx.reads.Put(key, nil)
+	return tx.base.Get(key)
+}
+
+// Put stores the (key,value) pair in the Txn. It returns true if the key was
+// not present as seen by the Txn.
+func (tx *Txn) Put(key KeyI, val interface{}) bool {
+	var _, found = tx.Get(key)
+	tx.writes.Put(key, val)
+	return !found
+}
+
+// Del deletes the key in the Txn. It returns the value the key had and true,
+// or nil and false if the key was not present as seen by the Txn.
+func (tx *Txn) Del(key KeyI) (interface{}, bool) {
+	var val, found = tx.Get(key)
+	if found {
+		tx.writes.Put(key, txnDeleted)
+	}
+	return val, found
+}
+
+// Commit installs the Txn's writes into its Ref and returns the new version.
+//
+// If any key the Txn read or wrote has changed between the version the Txn
+// started from and the current version of the Ref, nothing is installed and
+// Commit returns an error caused by ErrConflict. Keys the Txn did not touch
+// may have changed freely; their changes are kept.
+func (tx *Txn) Commit() (*HamtFunctional, error) {
+	if tx.done {
+		return nil, errors.New("Commit: transaction already committed")
+	}
+	tx.done = true
+
+	var r = tx.ref
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var cur = r.h
+	if cur != tx.base {
+		var err error
+		var check = func(key KeyI, _ interface{}) bool {
+			if keyChanged(&tx.base.hamtBase, &cur.hamtBase, key) {
+				err = errors.Wrapf(ErrConflict, "Commit: key %s changed", key)
+				return false
+			}
+			return true
+		}
+		tx.reads.Range(check)
+		if err == nil {
+			tx.writes.Range(check)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if tx.writes.IsEmpty() {
+		return cur, nil
+	}
+
+	var nh Hamt = cur
+	tx.writes.Range(func(key KeyI, val interface{}) bool {
+		if val == txnDeleted {
+			nh, _, _ = nh.Del(key)
+		} else {
+			nh, _ = nh.Put(key, val)
+		}
+		return true
+	})
+	r.h = nh.(*HamtFunctional)
+
+	return r.h, nil
+}
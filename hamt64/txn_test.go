@@ -0,0 +1,139 @@
+package hamt64_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/lleo/go-hamt/hamt64"
+	"github.com/pkg/errors"
+)
+
+func TestHamt64Txn(t *testing.T) {
+	var name = "TestHamt64Txn"
+	var kvs = KVS64[:1000]
+
+	var h, err = buildHamt64(name, kvs, true, TableOption)
+	if err != nil {
+		t.Fatalf("%s: buildHamt64 failed: %s", name, err)
+	}
+	var ref = hamt64.NewRef(h.(*hamt64.HamtFunctional))
+
+	var tx = ref.Begin()
+	if !tx.Put(hamt64.StringKey("new"), 1) {
+		t.Fatalf("%s: Put of a new key returned false", name)
+	}
+	if tx.Put(kvs[0].Key, -1) {
+		t.Fatalf("%s: Put of an existing key returned true", name)
+	}
+	var val, found = tx.Del(kvs[1].Key)
+	if !found || val != kvs[1].Val {
+		t.Fatalf("%s: Del(%s) = %v, %v", name, kvs[1].Key, val, found)
+	}
+	if _, found = tx.Get(kvs[1].Key); found {
+		t.Fatalf("%s: Get after Del found %s", name, kvs[1].Key)
+	}
+	if val, _ = tx.Get(kvs[0].Key); val != -1 {
+		t.Fatalf("%s: Get after Put = %v", name, val)
+	}
+
+	// writes are private until Commit
+	if val, _ = ref.Load().Get(kvs[0].Key); val != kvs[0].Val {
+		t.Fatalf("%s: uncommitted Put visible in Ref", name)
+	}
+
+	var nh *hamt64.HamtFunctional
+	nh, err = tx.Commit()
+	if err != nil {
+		t.Fatalf("%s: Commit failed: %s", name, err)
+	}
+	if ref.Load() != nh || nh.Nentries() != uint(len(kvs)) {
+		t.Fatalf("%s: Commit installed %d entries", name, nh.Nentries())
+	}
+	if val, _ = nh.Get(kvs[0].Key); val != -1 {
+		t.Fatalf("%s: committed Put lost", name)
+	}
+	if _, found = nh.Get(kvs[1].Key); found {
+		t.Fatalf("%s: committed Del lost", name)
+	}
+	if _, err = tx.Commit(); err == nil {
+		t.Fatalf("%s: second Commit did not fail", name)
+	}
+
+	// A concurrent commit to an untouched key does not conflict.
+	var tx1, tx2 = ref.Begin(), ref.Begin()
+	tx1.Get(kvs[10].Key)
+	tx1.Put(kvs[11].Key, "tx1")
+	tx2.Put(kvs[12].Key, "tx2")
+	if _, err = tx2.Commit(); err != nil {
+		t.Fatalf("%s: tx2.Commit failed: %s", name, err)
+	}
+	if _, err = tx1.Commit(); err != nil {
+		t.Fatalf("%s: tx1.Commit of disjoint keys failed: %s", name, err)
+	}
+	if val, _ = ref.Load().Get(kvs[12].Key); val != "tx2" {
+		t.Fatalf("%s: tx1.Commit lost tx2's write", name)
+	}
+
+	// but one to a key read or written does.
+	for _, kv := range []hamt64.KeyVal{{kvs[10].Key, "tx2"}, {kvs[11].Key, "tx2"}} {
+		tx1, tx2 = ref.Begin(), ref.Begin()
+		tx1.Get(kvs[10].Key)
+		tx1.Put(kvs[11].Key, "tx1")
+		tx2.Put(kv.Key, kv.Val)
+		if _, err = tx2.Commit(); err != nil {
+			t.Fatalf("%s: tx2.Commit failed: %s", name, err)
+		}
+		var before = ref.Load()
+		_, err = tx1.Commit()
+		if errors.Cause(err) != hamt64.ErrConflict {
+			t.Fatalf("%s: conflicting Commit on %s returned %v", name, kv.Key, err)
+		}
+		if ref.Load() != before {
+			t.Fatalf("%s: conflicting Commit modified the Ref", name)
+		}
+	}
+}
+
+func TestHamt64TxnUpdate(t *testing.T) {
+	var name = "TestHamt64TxnUpdate"
+	var counter = hamt64.StringKey("counter")
+
+	var ref = hamt64.NewRef(hamt64.NewFunctional(TableOption))
+
+	const goroutines, increments = 4, 250
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < increments; i++ {
+				ref.Update(func(tx *hamt64.Txn) error {
+					var n, _ = tx.Get(counter)
+					if n == nil {
+						n = 0
+					}
+					tx.Put(counter, n.(int)+1)
+					return nil
+				})
+			}
+		}()
+	}
+	wg.Wait()
+
+	var n, _ = ref.Load().Get(counter)
+	if n != goroutines*increments {
+		t.Fatalf("%s: counter = %v; expected %d", name, n, goroutines*increments)
+	}
+
+	var expected = errors.New("abandon")
+	var _, err = ref.Update(func(tx *hamt64.Txn) error {
+		tx.Put(counter, 0)
+		return expected
+	})
+	if err != expected {
+		t.Fatalf("%s: Update returned %v", name, err)
+	}
+	if n, _ = ref.Load().Get(counter); n != goroutines*increments {
+		t.Fatalf("%s: abandoned Update was committed", name)
+	}
+}
@@ -0,0 +1,176 @@
+package hamt64
+
+import (
+	"github.com/pkg/errors"
+)
+
+// Validate walks the entire hamtBase data structure and checks every
+// structural invariant the Hamt relies upon. It returns nil if the structure
+// is sound, otherwise it returns an error describing the first violation
+// found.
+//
+// The invariants checked are:
+//   - nentries equals the number of KeyVal pairs actually stored.
+//   - every table's depth and hashPath match its position in the trie.
+//   - a fixedTable's nents equals the number of non-nil slots.
+//   - a sparseTable's nodeMap population count equals len(nodes).
+//   - no table, other than the root table, is empty.
+//   - every node's hash prefix matches the hashPath of its table and the
+//     slot it is stored in.
+//   - every leaf's stored HashVal is the HashVal of its keys.
+//   - collisionLeafs hold at least two keys, all of the same HashVal, and
+//     no two of those keys are equal.
+//
+// Validate does NOT check that the trie is fully collapsed. A table holding a
+// single leaf, which could have been replaced by that leaf, is accepted; the
+// functional Del never collapses such tables. Nor does it reject a
+// collisionLeaf stored above maxDepth, which HamtTransient.Put allows.
+//
+// Validate is expensive; it is meant for tests and debugging.
+func (h *hamtBase) Validate() error {
+	var nkvs, err = validateTable(&h.root, 0, 0)
+	if err != nil {
+		return err
+	}
+
+	if nkvs != h.nentries {
+		return errors.Errorf(
+			"Validate: nentries,%d != number of KeyVals found,%d",
+			h.nentries, nkvs)
+	}
+
+	return nil
+}
+
+// validateTable checks the table t, which is expected to be located at depth
+// with the given hashPath, and recursively every node below it. It returns the
+// number of KeyVal pairs found.
+func validateTable(t tableI, depth uint, hashPath HashVal) (uint, error) {
+	var path = hashPath.HashPathString(depth)
+
+	switch x := t.(type) {
+	case *fixedTable:
+		if x.depth != depth {
+			return 0, errors.Errorf(
+				"Validate: fixedTable at %s has depth=%d; expected depth=%d",
+				path, x.depth, depth)
+		}
+		if x.hashPath != hashPath {
+			return 0, errors.Errorf(
+				"Validate: fixedTable at %s has hashPath=%s",
+				path, x.hashPath.HashPathString(depth))
+		}
+		var nonNils uint
+		for _, n := range x.nodes {
+			if n != nil {
+				nonNils++
+			}
+		}
+		if nonNils != x.nents {
+			return 0, errors.Errorf(
+				"Validate: fixedTable at %s has nents=%d but %d non-nil nodes",
+				path, x.nents, nonNils)
+		}
+	case *sparseTable:
+		if x.depth != depth {
+			return 0, errors.Errorf(
+				"Validate: sparseTable at %s has depth=%d; expected depth=%d",
+				path, x.depth, depth)
+		}
+		if x.hashPath != hashPath {
+			return 0, errors.Errorf(
+				"Validate: sparseTable at %s has hashPath=%s",
+				path, x.hashPath.HashPathString(depth))
+		}
+		if x.nodeMap.PopCount() != uint(len(x.nodes)) {
+			return 0, errors.Errorf(
+				"Validate: sparseTable at %s has nodeMap popcount=%d "+
+					"but len(nodes)=%d",
+				path, x.nodeMap.PopCount(), len(x.nodes))
+		}
+	default:
+		return 0, errors.Errorf("Validate: unknown table type %T at %s", t, path)
+	}
+
+	if depth > 0 && t.nentries() == 0 {
+		return 0, errors.Errorf("Validate: empty table at %s", path)
+	}
+
+	var nkvs uint
+	for idx := uint(0); idx < IndexLimit; idx++ {
+		var n = t.get(idx)
+		if n == nil {
+			continue
+		}
+
+		switch x := n.(type) {
+		case tableI:
+			if depth == maxDepth {
+				return 0, errors.Errorf(
+					"Validate: table found below maxDepth table at %s", path)
+			}
+			var childPath = hashPath.buildHashPath(idx, depth)
+			var cnt, err = validateTable(x, depth+1, childPath)
+			if err != nil {
+				return 0, err
+			}
+			nkvs += cnt
+		case leafI:
+			var cnt, err = validateLeaf(x)
+			if err != nil {
+				return 0, err
+			}
+			var hv = x.Hash()
+			if hv.hashPath(depth) != hashPath || hv.Index(depth) != idx {
+				return 0, errors.Errorf(
+					"Validate: leaf with hash %s stored in slot %d of table at %s",
+					hv, idx, path)
+			}
+			nkvs += cnt
+		default:
+			return 0, errors.Errorf(
+				"Validate: unknown node type %T in slot %d of table at %s",
+				n, idx, path)
+		}
+	}
+
+	return nkvs, nil
+}
+
+// validateLeaf checks the internal consistency of a leaf and returns the
+// number of KeyVal pairs it holds.
+func validateLeaf(l leafI) (uint, error) {
+	switch x := l.(type) {
+	case *flatLeaf:
+		if x.key == nil {
+			return 0, errors.New("Validate: flatLeaf with nil key")
+		}
+		if x.key.Hash() != x.hv {
+			return 0, errors.Errorf(
+				"Validate: flatLeaf key %v hash %s != stored hash %s",
+				x.key, x.key.Hash(), x.hv)
+		}
+		return 1, nil
+	case *collisionLeaf:
+		if len(x.kvs) < 2 {
+			return 0, errors.Errorf(
+				"Validate: collisionLeaf with %d KeyVals", len(x.kvs))
+		}
+		for i, kv := range x.kvs {
+			if kv.Key.Hash() != x.hv {
+				return 0, errors.Errorf(
+					"Validate: collisionLeaf key %v hash %s != stored hash %s",
+					kv.Key, kv.Key.Hash(), x.hv)
+			}
+			for _, kv2 := range x.kvs[i+1:] {
+				if kv.Key.Equals(kv2.Key) {
+					return 0, errors.Errorf(
+						"Validate: collisionLeaf has duplicate key %v", kv.Key)
+				}
+			}
+		}
+		return uint(len(x.kvs)), nil
+	}
+
+	return 0, errors.Errorf("Validate: unknown leaf type %T", l)
+}
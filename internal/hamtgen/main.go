@@ -0,0 +1,206 @@
+/*
+Command hamtgen generates the hamt32 package from the hamt64 package.
+
+hamt32 and hamt64 differ only in the width of HashVal, so hamt64 is the one
+implementation that is edited, and hamt32 is derived from it by renaming; see
+the rules below. Everything that depends on the width lives in hashwidth.go,
+which is written by hand for each package and never generated.
+
+Usage:
+
+	hamtgen [-check] src dst
+
+If src and dst are directories, every .go file of src, other than the files
+that only hamt64 has (see skip), is written to dst, and any file of dst that
+was generated from a file src no longer has is removed. Otherwise src and dst
+are files, and src is written to dst.
+
+With -check nothing is written; hamtgen exits with status 1 and lists the
+files of dst that are out of date.
+
+It is run by go generate in the hamt32 directory.
+*/
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// rules rename the hamt64 identifiers, in the names and contents of the
+// generated files, to their hamt32 equivalents.
+var rules = strings.NewReplacer(
+	"hamt64", "hamt32",
+	"Hamt64", "Hamt32",
+	"KVS64", "KVS32",
+	"kvs64", "kvs32",
+	"Build64", "Build32",
+	"fnv.New64()", "fnv.New32()",
+	".Sum64()", ".Sum32()",
+)
+
+// skip are the patterns of the hamt64 files that are not generated; either
+// they depend on the width of HashVal or they are only provided by hamt64.
+var skip = []string{
+	"doc.go",
+	"hashwidth.go",
+	"history.go",
+	"history_test.go",
+	"image*.go",
+}
+
+// header marks a generated file. It is followed by the name of the file it was
+// generated from, relative to the parent of its directory.
+const header = "// Code generated by hamtgen from "
+
+func main() {
+	var check bool
+	flag.BoolVar(&check, "check", false,
+		"List out of date files rather than writing them.")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: hamtgen [-check] src dst\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var files, stale, err = Generate(flag.Arg(0), flag.Arg(1))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "hamtgen:", err)
+		os.Exit(1)
+	}
+
+	var outOfDate []string
+	for _, name := range sortedNames(files) {
+		var cur, _ = ioutil.ReadFile(name)
+		if bytes.Equal(cur, files[name]) {
+			continue
+		}
+		outOfDate = append(outOfDate, name)
+		if !check {
+			err = ioutil.WriteFile(name, files[name], 0644)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "hamtgen:", err)
+				os.Exit(1)
+			}
+		}
+	}
+	for _, name := range stale {
+		outOfDate = append(outOfDate, name)
+		if !check {
+			err = os.Remove(name)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "hamtgen:", err)
+				os.Exit(1)
+			}
+		}
+	}
+
+	if check && len(outOfDate) > 0 {
+		for _, name := range outOfDate {
+			fmt.Fprintln(os.Stderr, "hamtgen: out of date:", name)
+		}
+		os.Exit(1)
+	}
+}
+
+// Generate returns the contents of the files generated from src, indexed by
+// the name they are written to, and the names of the files of dst that were
+// generated but whose source is gone.
+func Generate(src, dst string) (map[string][]byte, []string, error) {
+	var fi, err = os.Stat(src)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Generate")
+	}
+
+	var files = make(map[string][]byte)
+	if !fi.IsDir() {
+		files[dst], err = generateFile(src, filepath.Base(src))
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "Generate")
+		}
+		return files, nil, nil
+	}
+
+	var names []string
+	names, err = filepath.Glob(filepath.Join(src, "*.go"))
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Generate")
+	}
+	for _, name := range names {
+		if skipped(filepath.Base(name)) {
+			continue
+		}
+		var out = filepath.Join(dst, rules.Replace(filepath.Base(name)))
+		files[out], err = generateFile(name,
+			filepath.Base(src)+"/"+filepath.Base(name))
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "Generate")
+		}
+	}
+
+	var stale []string
+	names, err = filepath.Glob(filepath.Join(dst, "*.go"))
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Generate")
+	}
+	for _, name := range names {
+		if _, ok := files[name]; ok {
+			continue
+		}
+		var data []byte
+		data, err = ioutil.ReadFile(name)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "Generate")
+		}
+		if bytes.HasPrefix(data, []byte(header)) {
+			stale = append(stale, name)
+		}
+	}
+
+	return files, stale, nil
+}
+
+// generateFile returns the contents generated from the file src, which is
+// called name in the header.
+func generateFile(src, name string) ([]byte, error) {
+	var data, err = ioutil.ReadFile(src)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s%s; DO NOT EDIT.\n\n", header, name)
+	buf.WriteString(rules.Replace(string(data)))
+	return buf.Bytes(), nil
+}
+
+func skipped(name string) bool {
+	for _, pat := range skip {
+		if ok, _ := filepath.Match(pat, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedNames(files map[string][]byte) []string {
+	var names = make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
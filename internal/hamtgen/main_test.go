@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+// TestUpToDate fails if hamt64 has been changed without running go generate in
+// hamt32.
+func TestUpToDate(t *testing.T) {
+	var root = filepath.Join("..", "..")
+
+	var gens = []struct{ src, dst string }{
+		{filepath.Join(root, "hamt64"), filepath.Join(root, "hamt32")},
+		{filepath.Join(root, "hamt64_test.go"),
+			filepath.Join(root, "hamt32_test.go")},
+	}
+	for _, gen := range gens {
+		var files, stale, err = Generate(gen.src, gen.dst)
+		if err != nil {
+			t.Fatalf("Generate(%q, %q) failed: %s", gen.src, gen.dst, err)
+		}
+		for _, name := range sortedNames(files) {
+			var cur []byte
+			cur, err = ioutil.ReadFile(name)
+			if err != nil || !bytes.Equal(cur, files[name]) {
+				t.Errorf("%s is out of date; run go generate in hamt32", name)
+			}
+		}
+		for _, name := range stale {
+			t.Errorf("%s is stale; run go generate in hamt32", name)
+		}
+	}
+}
+
+func TestSkipped(t *testing.T) {
+	for _, name := range []string{"doc.go", "hashwidth.go", "image.go",
+		"image_mmap.go", "history_test.go"} {
+		if !skipped(name) {
+			t.Errorf("skipped(%q) = false", name)
+		}
+	}
+	for _, name := range []string{"hamt.go", "hashval.go", "hamt64_test.go"} {
+		if skipped(name) {
+			t.Errorf("skipped(%q) = true", name)
+		}
+	}
+}
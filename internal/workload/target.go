@@ -0,0 +1,189 @@
+package workload
+
+import (
+	"github.com/lleo/go-hamt/hamt32"
+	"github.com/lleo/go-hamt/hamt64"
+)
+
+// Stats is the width independent subset of hamt32.Stats and hamt64.Stats.
+type Stats struct {
+	MaxDepth       uint
+	Tables         uint
+	FixedTables    uint
+	SparseTables   uint
+	Leafs          uint
+	FlatLeafs      uint
+	CollisionLeafs uint
+	KeyVals        uint
+}
+
+// Target hides the hash width of the Hamt being measured. Keys are referred
+// to by their index into the keys the Target was made with, so the
+// conversion to a KeyI is not part of any timing.
+type Target interface {
+	Reset()
+	Put(i, val int) bool
+	Get(i int) bool
+	Del(i int) bool
+	Range() int
+	Nentries() uint
+	Stats() Stats
+	Validate() error
+}
+
+// NewTarget returns an empty Target of the given hash width, 32 or 64, mode,
+// and table option. keys are built-in keys, each wrapped in the KeyI
+// hamt32.WrapKey or hamt64.WrapKey gives it; NewTarget panics if one has
+// none.
+func NewTarget(width int, keys []interface{}, functional bool, tblOpt int) Target {
+	if width == 32 {
+		return newTarget32(keys, functional, tblOpt)
+	}
+	return newTarget64(keys, functional, tblOpt)
+}
+
+type target32 struct {
+	functional bool
+	tblOpt     int
+	keys       []hamt32.KeyI
+	h          hamt32.Hamt
+}
+
+func newTarget32(keys []interface{}, functional bool, tblOpt int) *target32 {
+	var t = &target32{functional: functional, tblOpt: tblOpt}
+	t.keys = make([]hamt32.KeyI, len(keys))
+	for i, k := range keys {
+		var key, err = hamt32.WrapKey(k)
+		if err != nil {
+			panic(err)
+		}
+		t.keys[i] = key
+	}
+	t.Reset()
+	return t
+}
+
+func (t *target32) Reset() {
+	t.h = hamt32.New(t.functional, t.tblOpt)
+}
+
+func (t *target32) Put(i, val int) bool {
+	var added bool
+	t.h, added = t.h.Put(t.keys[i], val)
+	return added
+}
+
+func (t *target32) Get(i int) bool {
+	var _, found = t.h.Get(t.keys[i])
+	return found
+}
+
+func (t *target32) Del(i int) bool {
+	var deleted bool
+	t.h, _, deleted = t.h.Del(t.keys[i])
+	return deleted
+}
+
+func (t *target32) Range() int {
+	var n int
+	t.h.Range(func(hamt32.KeyI, interface{}) bool {
+		n++
+		return true
+	})
+	return n
+}
+
+func (t *target32) Nentries() uint {
+	return t.h.Nentries()
+}
+
+func (t *target32) Stats() Stats {
+	var s = t.h.Stats()
+	return Stats{
+		MaxDepth:       s.MaxDepth,
+		Tables:         s.Tables,
+		FixedTables:    s.FixedTables,
+		SparseTables:   s.SparseTables,
+		Leafs:          s.Leafs,
+		FlatLeafs:      s.FlatLeafs,
+		CollisionLeafs: s.CollisionLeafs,
+		KeyVals:        s.KeyVals,
+	}
+}
+
+func (t *target32) Validate() error {
+	return t.h.Validate()
+}
+
+type target64 struct {
+	functional bool
+	tblOpt     int
+	keys       []hamt64.KeyI
+	h          hamt64.Hamt
+}
+
+func newTarget64(keys []interface{}, functional bool, tblOpt int) *target64 {
+	var t = &target64{functional: functional, tblOpt: tblOpt}
+	t.keys = make([]hamt64.KeyI, len(keys))
+	for i, k := range keys {
+		var key, err = hamt64.WrapKey(k)
+		if err != nil {
+			panic(err)
+		}
+		t.keys[i] = key
+	}
+	t.Reset()
+	return t
+}
+
+func (t *target64) Reset() {
+	t.h = hamt64.New(t.functional, t.tblOpt)
+}
+
+func (t *target64) Put(i, val int) bool {
+	var added bool
+	t.h, added = t.h.Put(t.keys[i], val)
+	return added
+}
+
+func (t *target64) Get(i int) bool {
+	var _, found = t.h.Get(t.keys[i])
+	return found
+}
+
+func (t *target64) Del(i int) bool {
+	var deleted bool
+	t.h, _, deleted = t.h.Del(t.keys[i])
+	return deleted
+}
+
+func (t *target64) Range() int {
+	var n int
+	t.h.Range(func(hamt64.KeyI, interface{}) bool {
+		n++
+		return true
+	})
+	return n
+}
+
+func (t *target64) Nentries() uint {
+	return t.h.Nentries()
+}
+
+func (t *target64) Stats() Stats {
+	var s = t.h.Stats()
+	return Stats{
+		MaxDepth:       s.MaxDepth,
+		Tables:         s.Tables,
+		FixedTables:    s.FixedTables,
+		SparseTables:   s.SparseTables,
+		Leafs:          s.Leafs,
+		FlatLeafs:      s.FlatLeafs,
+		CollisionLeafs: s.CollisionLeafs,
+		KeyVals:        s.KeyVals,
+	}
+}
+
+func (t *target64) Validate() error {
+	return t.h.Validate()
+}
@@ -0,0 +1,119 @@
+/*
+Package workload holds the workloads shared by the cmd/hamt tool and the bench
+package: a Target hiding the hash width of the Hamt being measured, key
+generation, access distributions, and heap measurement.
+*/
+package workload
+
+import (
+	"fmt"
+	"math/rand"
+	"runtime"
+)
+
+// The access distributions of AccessPattern and DeleteOrder.
+const (
+	Uniform    = "uniform"
+	Zipf       = "zipf"
+	Sequential = "sequential"
+)
+
+// The key types of RandomKeys.
+const (
+	IntKeys    = "int"
+	StringKeys = "string"
+	BytesKeys  = "bytes"
+)
+
+// ModeName returns the name of the Hamt mode functional selects.
+func ModeName(functional bool) string {
+	if functional {
+		return "functional"
+	}
+	return "transient"
+}
+
+// RandomKeys returns n keys of keyType, made from distinct random values of
+// the seed; so the same keys for the same arguments. IntKeys are int64s,
+// StringKeys strings, and BytesKeys []bytes.
+func RandomKeys(keyType string, n int, seed int64) []interface{} {
+	var rnd = rand.New(rand.NewSource(seed))
+	var seen = make(map[int64]bool, n)
+	var keys = make([]interface{}, 0, n)
+	for len(keys) < n {
+		var v = rnd.Int63()
+		if seen[v] {
+			continue
+		}
+		seen[v] = true
+
+		switch keyType {
+		case IntKeys:
+			keys = append(keys, v)
+		case StringKeys:
+			keys = append(keys, fmt.Sprintf("%016x", v))
+		default:
+			keys = append(keys, []byte(fmt.Sprintf("%016x", v)))
+		}
+	}
+	return keys
+}
+
+// StringKeysOf returns keys as a slice of the built-in keys a Target is made
+// with.
+func StringKeysOf(keys []string) []interface{} {
+	var ks = make([]interface{}, len(keys))
+	for i, k := range keys {
+		ks[i] = k
+	}
+	return ks
+}
+
+// AccessPattern returns count key indexes in [0, n) drawn from dist; Uniform,
+// Zipf with parameter zipfS, or Sequential. For the Zipf distribution the
+// ranks are mapped through a random permutation, so the hot keys are
+// scattered across the trie rather than being the first keys loaded.
+func AccessPattern(dist string, zipfS float64, rnd *rand.Rand, n, count int) []int {
+	var idxs = make([]int, count)
+
+	switch dist {
+	case Sequential:
+		for i := range idxs {
+			idxs[i] = i % n
+		}
+	case Zipf:
+		var perm = rnd.Perm(n)
+		var zipf = rand.NewZipf(rnd, zipfS, 1, uint64(n-1))
+		for i := range idxs {
+			idxs[i] = perm[zipf.Uint64()]
+		}
+	default: // Uniform
+		for i := range idxs {
+			idxs[i] = rnd.Intn(n)
+		}
+	}
+
+	return idxs
+}
+
+// DeleteOrder returns the order every one of n keys is deleted in. Each key
+// can only be deleted once, so the Zipf distribution falls back to the
+// uniform random order.
+func DeleteOrder(dist string, rnd *rand.Rand, n int) []int {
+	if dist == Sequential {
+		var order = make([]int, n)
+		for i := range order {
+			order[i] = i
+		}
+		return order
+	}
+	return rnd.Perm(n)
+}
+
+// HeapAlloc returns the number of live heap bytes after a full collection.
+func HeapAlloc() uint64 {
+	var ms runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&ms)
+	return ms.HeapAlloc
+}
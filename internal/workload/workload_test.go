@@ -0,0 +1,74 @@
+package workload_test
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/lleo/go-hamt/hamt64"
+	"github.com/lleo/go-hamt/internal/workload"
+)
+
+func TestTarget(t *testing.T) {
+	var n = 1000
+	for _, width := range []int{32, 64} {
+		for _, keyType := range []string{workload.IntKeys,
+			workload.StringKeys, workload.BytesKeys} {
+			var name = fmt.Sprintf("TestTarget:%d:%s", width, keyType)
+			var keys = workload.RandomKeys(keyType, n, 1)
+			var tgt = workload.NewTarget(width, keys, true, hamt64.HybridTables)
+
+			for i := range keys {
+				if !tgt.Put(i, i) {
+					t.Fatalf("%s: Put(%d) did not add it", name, i)
+				}
+			}
+			if tgt.Nentries() != uint(n) || tgt.Range() != n ||
+				tgt.Stats().KeyVals != uint(n) {
+				t.Fatalf("%s: Target does not hold %d entries", name, n)
+			}
+			if err := tgt.Validate(); err != nil {
+				t.Fatalf("%s: Validate() failed: %s", name, err)
+			}
+			for i := range keys {
+				if !tgt.Get(i) || !tgt.Del(i) {
+					t.Fatalf("%s: Get or Del of key #%d failed", name, i)
+				}
+			}
+			if tgt.Nentries() != 0 {
+				t.Fatalf("%s: Nentries(),%d != 0", name, tgt.Nentries())
+			}
+		}
+	}
+}
+
+func TestAccessPattern(t *testing.T) {
+	var n, count = 100, 10000
+	for _, dist := range []string{workload.Uniform, workload.Zipf,
+		workload.Sequential} {
+		var idxs = workload.AccessPattern(dist, 1.1,
+			rand.New(rand.NewSource(1)), n, count)
+		if len(idxs) != count {
+			t.Fatalf("TestAccessPattern:%s: %d indexes; expected %d",
+				dist, len(idxs), count)
+		}
+		for i, idx := range idxs {
+			if idx < 0 || idx >= n {
+				t.Fatalf("TestAccessPattern:%s: index %d out of range", dist, idx)
+			}
+			if dist == workload.Sequential && idx != i%n {
+				t.Fatalf("TestAccessPattern:%s: index #%d is %d", dist, i, idx)
+			}
+		}
+
+		var order = workload.DeleteOrder(dist, rand.New(rand.NewSource(1)), n)
+		var seen = make(map[int]bool)
+		for _, idx := range order {
+			seen[idx] = true
+		}
+		if len(order) != n || len(seen) != n {
+			t.Fatalf("TestAccessPattern:%s: DeleteOrder is not a permutation",
+				dist)
+		}
+	}
+}
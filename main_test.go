@@ -37,6 +37,15 @@ var KVS []KeyVal
 var Functional bool
 var TableOption int
 
+// ValidateInterval is how often the big Put and Del suites call Validate().
+// Validate() walks the entire Hamt, so calling it after every one of numKvs
+// mutations would never finish; see the Validate tests for a bounded suite
+// that validates after every mutation.
+var ValidateInterval = 64 * 1024
+
+// ValidateNumKvs is the number of KeyVals used by the Validate tests.
+var ValidateNumKvs = 4 * 1024
+
 var Hamt32 hamt32.Hamt
 var Hamt64 hamt64.Hamt
 
@@ -345,6 +354,25 @@ func buildHamt64(
 	return h, nil
 }
 
+// validator is implemented by both hamt32.Hamt and hamt64.Hamt.
+type validator interface {
+	Validate() error
+}
+
+// validateHamt is called after the i'th mutation of h. It only calls
+// h.Validate() when i is a multiple of ValidateInterval.
+func validateHamt(t testing.TB, name string, h validator, i int) {
+	if i%ValidateInterval != 0 {
+		return
+	}
+
+	var err = h.Validate()
+	if err != nil {
+		log.Printf("%s: Validate() failed after mutation #%d: %s", name, i, err)
+		t.Fatalf("%s: Validate() failed after mutation #%d: %s", name, i, err)
+	}
+}
+
 func RunTimes() string {
 	// Grab list of keys from RunTime map; MAJOR un-feature of Go!
 	var ks = make([]string, len(RunTime))
@@ -381,6 +409,9 @@ func TestConstantsInSync(t *testing.T) {
 	if hamt.HybridTables != hamt32.HybridTables {
 		t.Fatal("hamt.HybridTables != hamt32.HybridTables")
 	}
+	if hamt.CompactTables != hamt32.CompactTables {
+		t.Fatal("hamt.CompactTables != hamt32.CompactTables")
+	}
 	if hamt.TableOptionName != hamt32.TableOptionName {
 		t.Fatal("TableOptionName != hamt32.TableOptionName")
 	}
@@ -394,6 +425,9 @@ func TestConstantsInSync(t *testing.T) {
 	if hamt.HybridTables != hamt64.HybridTables {
 		t.Fatal("hamt.HybridTables != hamt64.HybridTables")
 	}
+	if hamt.CompactTables != hamt64.CompactTables {
+		t.Fatal("hamt.CompactTables != hamt64.CompactTables")
+	}
 	if hamt.TableOptionName != hamt64.TableOptionName {
 		t.Fatal("TableOptionName != hamt64.TableOptionName")
 	}
@@ -409,7 +443,37 @@ func TestConstantsInSync(t *testing.T) {
 	if hamt32.HybridTables != hamt64.HybridTables {
 		t.Fatal("hamt32.HybridTables != hamt64.HybridTables")
 	}
+	if hamt32.CompactTables != hamt64.CompactTables {
+		t.Fatal("hamt32.CompactTables != hamt64.CompactTables")
+	}
 	if hamt32.TableOptionName != hamt64.TableOptionName {
 		t.Fatal("hamt32.TableOptionName != hamt64.TableOptionName")
 	}
 }
+
+// BenchmarkCompactTables compares the CHAMP layout of CompactTables with
+// HybridTables for both hash widths, over the same keys as the other
+// benchmarks.
+func BenchmarkCompactTables(b *testing.B) {
+	for _, tblOpt := range []int{hamt.HybridTables, hamt.CompactTables} {
+		var tblOpt = tblOpt
+		var optName = hamt.TableOptionName[tblOpt]
+
+		b.Run("Get32/"+optName, func(b *testing.B) {
+			b.ReportAllocs()
+			runBenchmarkHamt32Get(b, KVS, Functional, tblOpt)
+		})
+		b.Run("Get64/"+optName, func(b *testing.B) {
+			b.ReportAllocs()
+			runBenchmarkHamt64Get(b, KVS, Functional, tblOpt)
+		})
+		b.Run("Put32/"+optName, func(b *testing.B) {
+			b.ReportAllocs()
+			runBenchmarkHamt32Put(b, KVS, Functional, tblOpt)
+		})
+		b.Run("Put64/"+optName, func(b *testing.B) {
+			b.ReportAllocs()
+			runBenchmarkHamt64Put(b, KVS, Functional, tblOpt)
+		})
+	}
+}